@@ -5,23 +5,21 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/hmac"
-	"crypto/rand"
 	"crypto/sha1" //nolint:gosec // SHA1 is required by the protocol
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"strconv"
-	"time"
-
-	"golang.org/x/crypto/ssh"
 )
 
 const (
 	apiEndpointQuery     = "/api/dataset/query"
 	apiEndpointNewObject = "/api/dataset/new_object"
+	apiEndpointCommit    = "/api/dataset/commit"
 )
 
 // ServerObjects is a slice of ServerObjects
@@ -29,83 +27,180 @@ type ServerObjects []ServerObject
 
 // ServerObject is a map of key-value attributes of a SA object
 type ServerObject struct {
-	// the actual SA attributes of the object
+	// the actual SA attributes of the object, including uncommitted changes
 	attributes map[string]any
-	// todo: place for dirty changes + .Set()/.Commit() etc here
+	// original values of all attributes which were modified via Set() since the last commit. They
+	// are sent as "old" value, so SA can reject the commit when someone else changed them meanwhile.
+	oldValues map[string]any
+	// state shared by all copies of the object, like the maps above
+	state *objectState
+	// the client which loaded the object, nil for the default client
+	client *Client
+}
+
+// objectState is the pending state of an object which is not stored in its attributes
+type objectState struct {
+	// the object was created via NewObject() and is not committed yet
+	isNew bool
+	// the object will be deleted with the next commit
+	deleted bool
+}
+
+// newServerObject wraps the attributes returned by SA into a ServerObject
+func newServerObject(attributes map[string]any) ServerObject {
+	return ServerObject{
+		attributes: attributes,
+		oldValues:  make(map[string]any),
+		state:      &objectState{},
+	}
+}
+
+// NewServerObject creates an object from attributes loaded elsewhere, like a cache of query results,
+// to use the typed getters or Filters.Match() on it. Commits of it are sent via the default client.
+func NewServerObject(attributes map[string]any) ServerObject {
+	return newServerObject(maps.Clone(attributes))
 }
 
-// Get safely retrieves an attribute, converting JSON float64 numbers to int when needed
+// Get safely retrieves an attribute. Numbers are returned as int, or as float64 if they have a fraction.
 func (s ServerObject) Get(attribute string) any {
 	if val, ok := s.attributes[attribute]; ok {
-		if floatVal, isFloat := val.(float64); isFloat {
-			return int(floatVal)
-		}
-		return val
+		return normalizeValue(val)
 	}
 	return nil
 }
 
-// GetString safely retrieves an attribute as a string
-func (s ServerObject) GetString(attribute string) any {
-	val := s.Get(attribute)
-	if strVal, isString := val.(string); isString {
-		return strVal
+// normalizeValue converts JSON numbers to int, or to float64 if they have a fraction. The values of multi
+// attributes are converted in a copy.
+func normalizeValue(val any) any {
+	switch typed := val.(type) {
+	case []any:
+		normalized := make([]any, len(typed))
+		for idx, elem := range typed {
+			normalized[idx] = normalizeValue(elem)
+		}
+		return normalized
+	case json.Number:
+		if intVal, err := typed.Int64(); err == nil {
+			return int(intVal)
+		}
+		if floatVal, err := typed.Float64(); err == nil {
+			return floatVal
+		}
+	case float64:
+		if intVal, isInt := toInt(typed); isInt {
+			return intVal
+		}
 	}
-	return nil
+	return val
 }
 
-// ObjectID returns the "object_id" attribute of the ServerObject
+// GetString retrieves a string attribute
+func (s ServerObject) GetString(attribute string) (string, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return "", err
+	}
+
+	strVal, ok := val.(string)
+	if !ok {
+		return "", typeError(attribute, "a string", val)
+	}
+	return strVal, nil
+}
+
+// ObjectID returns the "object_id" attribute of the ServerObject, 0 if the object is not created yet
 func (s ServerObject) ObjectID() int {
-	return s.Get("object_id").(int)
+	objectID, _ := s.GetInt("object_id")
+	return objectID
 }
 
-func sendRequest(endpoint string, postData any) (*http.Response, error) {
-	config, err := getConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get config: %w", err)
+// sendRequest sends the request to SA and retries it according to the retry policy of the client
+func (c *Client) sendRequest(ctx context.Context, endpoint string, postData any) (*http.Response, error) {
+	postStr, _ := json.Marshal(postData)
+
+	// commits are not idempotent: when the response got lost, a retry might apply the changes twice
+	idempotent := endpoint != apiEndpointCommit
+
+	reauthenticated, resynced := false, false
+	for attempt := 1; ; attempt++ {
+		resp, err := c.sendRequestOnce(ctx, endpoint, postStr)
+		// rejected requests weren't processed, so even commits are sent again. Not counted as attempt.
+		if !resynced && c.resyncClock(err) {
+			resynced = true
+			attempt--
+			continue
+		}
+		if !reauthenticated && c.invalidateCredentials(err) {
+			reauthenticated = true
+			attempt--
+			continue
+		}
+		if err == nil || !c.retryPolicy.shouldRetry(ctx, err, attempt, idempotent) {
+			return resp, err
+		}
+
+		if waitErr := c.retryPolicy.wait(ctx, attempt); waitErr != nil {
+			return nil, fmt.Errorf("%w (retry aborted: %w)", err, waitErr)
+		}
 	}
+}
 
-	postStr, _ := json.Marshal(postData)
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, config.baseURL+endpoint, bytes.NewBuffer(postStr))
+// invalidateCredentials drops cached credentials of an ExpiringAuthenticator, if SA rejected them
+func (c *Client) invalidateCredentials(err error) bool {
+	expiring, ok := c.authenticator.(ExpiringAuthenticator)
+	if !ok || !isRejected(err) {
+		return false
+	}
+
+	expiring.Invalidate()
+	return true
+}
+
+// isRejected checks if SA rejected the authentication of the request with 401 or 403
+func isRejected(err error) bool {
+	var statusErr *httpError
+	return errors.As(err, &statusErr) &&
+		(statusErr.statusCode == http.StatusUnauthorized || statusErr.statusCode == http.StatusForbidden)
+}
+
+// sendRequestOnce signs the request with the current timestamp and sends it
+func (c *Client) sendRequestOnce(ctx context.Context, endpoint string, postStr []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, bytes.NewBuffer(postStr))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	now := time.Now().Unix()
+	// fetch the credentials before taking the timestamp, which might take a while
+	if prepared, ok := c.authenticator.(preparedAuthenticator); ok {
+		if err = prepared.prepare(ctx); err != nil {
+			return nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	now := c.now().Unix()
 	req.Header.Set("Content-Type", "application/x-json")
 	req.Header.Set("X-Timestamp", strconv.FormatInt(now, 10))
-	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("User-Agent", c.userAgent)
 	req.Header.Set("Accept-Encoding", "gzip")
 
-	if config.sshSigner != nil {
-		// sign with private key or SSH agent
-		messageToSign := calcMessage(now, postStr)
-		signature, sigErr := config.sshSigner.Sign(rand.Reader, messageToSign)
-		if sigErr != nil {
-			return nil, fmt.Errorf("failed to sign request: %w", sigErr)
-		}
-		publicKey := base64.StdEncoding.EncodeToString(config.sshSigner.PublicKey().Marshal())
-		sshSignature := base64.StdEncoding.EncodeToString(ssh.Marshal(signature))
-
-		req.Header.Set("X-PublicKeys", publicKey)
-		req.Header.Set("X-Signatures", sshSignature)
-	} else if len(config.authToken) > 0 {
-		req.Header.Set("X-SecurityToken", calcSecurityToken(config.authToken, now, postStr))
-		req.Header.Set("X-Application", calcAppID(config.authToken))
+	if err = c.authenticator.Authenticate(req.Header, now, postStr); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	c.measureClockSkew(resp)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		defer resp.Body.Close()
 
+		statusErr := &httpError{statusCode: resp.StatusCode}
 		bodyBytes, readErr := io.ReadAll(resp.Body)
 		if readErr != nil {
-			return nil, fmt.Errorf("HTTP error %d %s (failed to read error details: %w)",
-				resp.StatusCode, http.StatusText(resp.StatusCode), readErr)
+			statusErr.err = fmt.Errorf("failed to read error details: %w", readErr)
+			return nil, statusErr
 		}
 
 		var nestedErrorResp struct {
@@ -114,12 +209,10 @@ func sendRequest(endpoint string, postData any) (*http.Response, error) {
 			} `json:"error"`
 		}
 		if jsonErr := json.Unmarshal(bodyBytes, &nestedErrorResp); jsonErr == nil && nestedErrorResp.Error.Message != "" {
-			return nil, fmt.Errorf("HTTP error %d %s: %s",
-				resp.StatusCode, http.StatusText(resp.StatusCode), nestedErrorResp.Error.Message)
+			statusErr.message = nestedErrorResp.Error.Message
 		}
 
-		// If body is empty, just return the status code
-		return nil, fmt.Errorf("HTTP error %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return nil, statusErr
 	}
 
 	// If the server responded with gzip encoding, wrap the response body accordingly.
@@ -141,6 +234,41 @@ func sendRequest(endpoint string, postData any) (*http.Response, error) {
 	return resp, nil
 }
 
+// httpError is returned when SA responds with a non 2xx status code
+type httpError struct {
+	statusCode int
+	// the error message sent by SA, if any
+	message string
+	// set if the response body could not be read
+	err error
+}
+
+func (e *httpError) Error() string {
+	msg := fmt.Sprintf("HTTP error %d %s", e.statusCode, http.StatusText(e.statusCode))
+	if e.err != nil {
+		return fmt.Sprintf("%s (%s)", msg, e.err)
+	}
+	if e.message != "" {
+		return msg + ": " + e.message
+	}
+
+	// If body is empty, just return the status code
+	return msg
+}
+
+func (e *httpError) Unwrap() error {
+	return e.err
+}
+
+// decodeResponse decodes a JSON response body. Numbers are kept as json.Number to
+// not lose precision of large integers and to distinguish integers from floats.
+func decodeResponse(body io.Reader, target any) error {
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+
+	return decoder.Decode(target)
+}
+
 // gzipReadCloser wraps a gzip.Reader so that
 // closing it also closes the underlying body.
 type gzipReadCloser struct {
@@ -1,30 +1,38 @@
 package adminapi
 
 import (
+	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// Because getConfig in config.go calls sync.OnceValues, the new values set to
-// SERVERADMIN_BASE_URL between test runs is never changed, as getConfig returns
-// cached values.
-// We use resetConfig() to reinitialize things, forcing getConfig() to return the
-// values from the new env variables.
+// Because getDefaultClient in client.go calls sync.OnceValues, the new values set to
+// SERVERADMIN_BASE_URL between test runs is never changed, as getDefaultClient returns
+// a cached client.
+// We use resetConfig() to reinitialize things, forcing getDefaultClient() to return a
+// client configured by the new env variables.
 func resetConfig() {
-	getConfig = sync.OnceValues(loadConfig)
+	getDefaultClient = sync.OnceValues(func() (*Client, error) {
+		return NewClientFromEnv()
+	})
 }
 
 func TestFakeServer(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		req, _ := io.ReadAll(r.Body)
-		// todo: assert signature etc...create more useful test cases
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		assert.Equal(t, calcSecurityToken([]byte("1234567890"), timestamp, req), r.Header.Get("X-SecurityToken"))
+		assert.Equal(t, calcAppID([]byte("1234567890")), r.Header.Get("X-Application"))
 
 		expectedRequest := `{"filters":{"hostname":{"Any":[{"Regexp":"test.foo.local"},{"Regexp":".*\\.bar.local"}]}},"restrict":["hostname","object_id"]}`
 		assert.Equal(t, expectedRequest, string(req))
@@ -52,18 +60,106 @@ func TestFakeServer(t *testing.T) {
 
 	object := servers[0]
 	assert.Equal(t, "foo.bar.local", object.Get("hostname"))
-	assert.Equal(t, "foo.bar.local", object.GetString("hostname"))
+	hostname, err := object.GetString("hostname")
+	require.NoError(t, err)
+	assert.Equal(t, "foo.bar.local", hostname)
 	assert.Equal(t, 483903, object.Get("object_id"))
 	assert.Equal(t, 483903, object.ObjectID())
-	assert.Nil(t, object.GetString("object_id"))
+	_, err = object.GetString("object_id")
+	require.EqualError(t, err, "attribute object_id is not a string: 483903 (int)")
 	assert.Nil(t, object.Get("nope"))
-	assert.Nil(t, object.GetString("nope"))
+	_, err = object.GetString("nope")
+	require.EqualError(t, err, "attribute nope is not loaded, add it via Query.SetAttributes()")
 
 	one, err := query.One()
 	require.NoError(t, err)
 	assert.Equal(t, 483903, one.Get("object_id"))
 }
 
+func TestPreciseNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		resp := `{"status": "success", "result": [{"object_id": 9007199254740993, "load": 2.5, "memory": 2048, "ports": [80, 443]}]}`
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(resp))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	query := NewQuery(Filters{"hostname": "foo.bar.local"})
+	query.SetAttributes([]string{"load", "memory", "ports"})
+
+	object, err := query.One()
+	require.NoError(t, err)
+	assert.Equal(t, 9007199254740993, object.ObjectID())
+	assert.Equal(t, 2.5, object.Get("load"))
+	assert.Equal(t, 2048, object.Get("memory"))
+
+	assert.Equal(t, []any{80, 443}, object.Get("ports"))
+
+	ports, err := object.GetMulti("ports")
+	require.NoError(t, err)
+	assert.Equal(t, []any{80, 443}, ports.Values())
+	assert.True(t, ports.Contains(443))
+
+	// numbers are sent back to SA exactly as they were received
+	require.NoError(t, object.Set("memory", 4096))
+	assert.Equal(t, map[string]any{
+		"object_id": 9007199254740993,
+		"memory":    map[string]any{"action": "update", "old": json.Number("2048"), "new": 4096},
+	}, object.changeSet())
+	require.NoError(t, object.Set("ports", []int{80, 8080}))
+	assert.Equal(t, []Change{
+		{Attribute: "memory", Old: 2048, New: 4096},
+		{Attribute: "ports", Old: []any{80, 443}, New: []any{80, 8080}},
+	}, object.Changes())
+
+	var decoded struct {
+		Ports any `sa:"ports"`
+	}
+	require.NoError(t, object.Decode(&decoded))
+	assert.Equal(t, []any{80, 8080}, decoded.Ports)
+}
+
+func TestContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// block until the client gives up, the body has to be consumed to notice it
+		_, _ = io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL), WithToken("1234567890"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.AllContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.NewObjectContext(canceled, "vm")
+	require.ErrorIs(t, err, context.Canceled)
+
+	object := newServerObject(map[string]any{"object_id": 1.0, "state": "online"})
+	object.client = client
+	require.NoError(t, object.Set("state", "maintenance"))
+	require.ErrorIs(t, object.CommitContext(canceled), context.Canceled)
+	assert.True(t, object.HasChanges())
+}
+
 // just some simple example tests, e2e tests might make much more sense here for full coverage
 func TestAppId(t *testing.T) {
 	testCases := []struct {
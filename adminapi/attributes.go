@@ -0,0 +1,238 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/netip"
+	"reflect"
+	"time"
+)
+
+// timeLayouts are the formats SA uses for date and datetime attributes
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	time.DateTime,
+	time.DateOnly,
+}
+
+// GetInt retrieves an integer attribute
+func (s ServerObject) GetInt(attribute string) (int, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return 0, err
+	}
+
+	intVal, ok := toInt(val)
+	if !ok {
+		return 0, typeError(attribute, "an integer", val)
+	}
+	return intVal, nil
+}
+
+// GetFloat retrieves a numeric attribute as float64
+func (s ServerObject) GetFloat(attribute string) (float64, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return 0, err
+	}
+
+	floatVal, ok := toFloat(val)
+	if !ok {
+		return 0, typeError(attribute, "a number", val)
+	}
+	return floatVal, nil
+}
+
+// GetBool retrieves a boolean attribute
+func (s ServerObject) GetBool(attribute string) (bool, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return false, err
+	}
+
+	boolVal, ok := val.(bool)
+	if !ok {
+		return false, typeError(attribute, "a boolean", val)
+	}
+	return boolVal, nil
+}
+
+// GetStringSlice retrieves a multi attribute with string values
+func (s ServerObject) GetStringSlice(attribute string) ([]string, error) {
+	return getSlice(s, attribute, "strings", func(val any) (string, bool) {
+		strVal, ok := val.(string)
+		return strVal, ok
+	})
+}
+
+// GetIntSlice retrieves a multi attribute with integer values
+func (s ServerObject) GetIntSlice(attribute string) ([]int, error) {
+	return getSlice(s, attribute, "integers", toInt)
+}
+
+// GetTime retrieves a date or datetime attribute
+func (s ServerObject) GetTime(attribute string) (time.Time, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	timeVal, ok := toTime(val)
+	if !ok {
+		return time.Time{}, typeError(attribute, "a date", val)
+	}
+	return timeVal, nil
+}
+
+// GetIP retrieves an IP address attribute. Single-host networks like "10.0.0.1/32" are accepted as well.
+func (s ServerObject) GetIP(attribute string) (netip.Addr, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	addr, ok := toAddr(val)
+	if !ok {
+		return netip.Addr{}, typeError(attribute, "an IP address", val)
+	}
+	return addr, nil
+}
+
+// GetPrefix retrieves an IP network attribute. Plain IP addresses are returned as single-host networks.
+func (s ServerObject) GetPrefix(attribute string) (netip.Prefix, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	prefix, ok := toPrefix(val)
+	if !ok {
+		return netip.Prefix{}, typeError(attribute, "an IP network", val)
+	}
+	return prefix, nil
+}
+
+// lookup returns the raw value of a loaded attribute
+func (s ServerObject) lookup(attribute string) (any, error) {
+	val, ok := s.attributes[attribute]
+	if !ok {
+		return nil, fmt.Errorf("attribute %s is not loaded, add it via Query.SetAttributes()", attribute)
+	}
+	return val, nil
+}
+
+func getSlice[T any](s ServerObject, attribute string, typeNames string, convert func(any) (T, bool)) ([]T, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := val.([]any)
+	if !ok {
+		return nil, fmt.Errorf("attribute %s is not a multi attribute", attribute)
+	}
+
+	result := make([]T, len(values))
+	for idx, value := range values {
+		if result[idx], ok = convert(value); !ok {
+			return nil, typeError(attribute, "a list of "+typeNames, value)
+		}
+	}
+	return result, nil
+}
+
+func typeError(attribute string, expected string, val any) error {
+	val = normalizeValue(val)
+	return fmt.Errorf("attribute %s is not %s: %v (%T)", attribute, expected, val, val)
+}
+
+// toInt converts any numeric value to int, as long as no precision is lost
+func toInt(val any) (int, bool) {
+	if num, isNumber := val.(json.Number); isNumber {
+		if intVal, err := num.Int64(); err == nil {
+			return int(intVal), true
+		}
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() { //nolint:exhaustive // all other kinds are handled as float
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint()), rv.Uint() <= math.MaxInt64
+	}
+
+	floatVal, ok := toFloat(val)
+	if !ok || floatVal != math.Trunc(floatVal) || floatVal < math.MinInt64 || floatVal >= math.MaxInt64 {
+		return 0, false
+	}
+	return int(floatVal), true
+}
+
+func toFloat(val any) (float64, bool) {
+	if num, isNumber := val.(json.Number); isNumber {
+		floatVal, err := num.Float64()
+		return floatVal, err == nil
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() { //nolint:exhaustive // all other kinds are no numbers
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func toTime(val any) (time.Time, bool) {
+	switch typed := val.(type) {
+	case time.Time:
+		return typed, true
+	case string:
+		for _, layout := range timeLayouts {
+			if timeVal, err := time.Parse(layout, typed); err == nil {
+				return timeVal, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toAddr(val any) (netip.Addr, bool) {
+	switch typed := val.(type) {
+	case netip.Addr:
+		return typed, true
+	case string:
+		if addr, err := netip.ParseAddr(typed); err == nil {
+			return addr, true
+		}
+		if prefix, err := netip.ParsePrefix(typed); err == nil && prefix.IsSingleIP() {
+			return prefix.Addr(), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+func toPrefix(val any) (netip.Prefix, bool) {
+	switch typed := val.(type) {
+	case netip.Prefix:
+		return typed, true
+	case netip.Addr:
+		return netip.PrefixFrom(typed, typed.BitLen()), typed.IsValid()
+	case string:
+		if prefix, err := netip.ParsePrefix(typed); err == nil {
+			return prefix, true
+		}
+		if addr, err := netip.ParseAddr(typed); err == nil {
+			return netip.PrefixFrom(addr, addr.BitLen()), true
+		}
+	}
+	return netip.Prefix{}, false
+}
@@ -0,0 +1,92 @@
+package adminapi
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedAccessors(t *testing.T) {
+	object := newServerObject(map[string]any{
+		"object_id":   483903.0,
+		"hostname":    "foo.bar.local",
+		"memory":      2048.0,
+		"load":        2.5,
+		"active":      true,
+		"tags":        []any{"web", "prod"},
+		"ports":       []any{80.0, 443.0},
+		"created":     "2024-01-02T10:11:12+00:00",
+		"birthday":    "2024-01-02",
+		"intern_ip":   "10.0.0.1",
+		"primary_ip6": "2a00:1f78::1/128",
+		"network":     "10.0.0.0/24",
+		"nothing":     nil,
+	})
+
+	memory, err := object.GetInt("memory")
+	require.NoError(t, err)
+	assert.Equal(t, 2048, memory)
+	_, err = object.GetInt("load")
+	require.EqualError(t, err, "attribute load is not an integer: 2.5 (float64)")
+	_, err = object.GetInt("nothing")
+	require.EqualError(t, err, "attribute nothing is not an integer: <nil> (<nil>)")
+
+	// Get doesn't truncate real floats anymore
+	assert.Equal(t, 2.5, object.Get("load"))
+	assert.Equal(t, 2048, object.Get("memory"))
+
+	load, err := object.GetFloat("load")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.5, load, 0)
+	_, err = object.GetFloat("hostname")
+	require.EqualError(t, err, "attribute hostname is not a number: foo.bar.local (string)")
+
+	active, err := object.GetBool("active")
+	require.NoError(t, err)
+	assert.True(t, active)
+	_, err = object.GetBool("hostname")
+	require.Error(t, err)
+
+	tags, err := object.GetStringSlice("tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web", "prod"}, tags)
+	_, err = object.GetStringSlice("ports")
+	require.EqualError(t, err, "attribute ports is not a list of strings: 80 (int)")
+	_, err = object.GetStringSlice("hostname")
+	require.EqualError(t, err, "attribute hostname is not a multi attribute")
+
+	ports, err := object.GetIntSlice("ports")
+	require.NoError(t, err)
+	assert.Equal(t, []int{80, 443}, ports)
+
+	created, err := object.GetTime("created")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 10, 11, 12, 0, time.UTC), created.UTC())
+	birthday, err := object.GetTime("birthday")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), birthday)
+	_, err = object.GetTime("hostname")
+	require.Error(t, err)
+
+	ip, err := object.GetIP("intern_ip")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), ip)
+	ip6, err := object.GetIP("primary_ip6")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("2a00:1f78::1"), ip6)
+	_, err = object.GetIP("network")
+	require.EqualError(t, err, "attribute network is not an IP address: 10.0.0.0/24 (string)")
+
+	network, err := object.GetPrefix("network")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/24"), network)
+	hostNetwork, err := object.GetPrefix("intern_ip")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.1/32"), hostNetwork)
+
+	_, err = object.GetPrefix("nope")
+	require.EqualError(t, err, "attribute nope is not loaded, add it via Query.SetAttributes()")
+}
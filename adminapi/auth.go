@@ -0,0 +1,250 @@
+package adminapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Authenticator authenticates a request to SA by setting headers. It's called for every attempt
+// with the unix timestamp sent as X-Timestamp and the request body, which the built-in schemes sign.
+type Authenticator interface {
+	Authenticate(header http.Header, timestamp int64, body []byte) error
+}
+
+// ExpiringAuthenticator is an Authenticator with cached credentials which might expire, like tokens
+// from a credential helper. When SA rejects a request with 401 or 403, Invalidate() is called and the
+// request is sent once more, so the next Authenticate() call has to fetch fresh credentials.
+type ExpiringAuthenticator interface {
+	Authenticator
+	Invalidate()
+}
+
+// AuthenticatorFunc allows using a plain function as Authenticator
+type AuthenticatorFunc func(header http.Header, timestamp int64, body []byte) error
+
+// Authenticate calls f(header, timestamp, body)
+func (f AuthenticatorFunc) Authenticate(header http.Header, timestamp int64, body []byte) error {
+	return f(header, timestamp, body)
+}
+
+// NewTokenAuth authenticates with a security token, sending a HMAC of the request instead of the token itself
+func NewTokenAuth(token string) Authenticator {
+	return tokenAuth(token)
+}
+
+// NewTokenFileAuth authenticates with the security token stored in the file. The file is read on the first
+// request and again when SA rejects the token, so it can be rotated while the client is running.
+func NewTokenFileAuth(path string) Authenticator {
+	return &cachedTokenAuth{fetch: func(context.Context) (string, error) {
+		token, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token file: %w", err)
+		}
+		return string(token), nil
+	}}
+}
+
+// tokenFetchTimeout limits how long a token command may run, like a password manager waiting to be unlocked
+var tokenFetchTimeout = time.Minute
+
+// NewTokenCommandAuth authenticates with the security token printed by the command, like a credential helper
+// of a password manager. Like git credential helpers, the command is run by the shell on the first request
+// and the token is cached until SA rejects it. The command is killed after one minute.
+func NewTokenCommandAuth(command string) Authenticator {
+	return &cachedTokenAuth{fetch: func(ctx context.Context) (string, error) {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+		cmd.Stderr = &stderr
+		// don't wait for children of the shell which keep stdout open after it got killed
+		cmd.WaitDelay = time.Second
+		token, err := cmd.Output()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("no token after %s: %w", tokenFetchTimeout, ctx.Err())
+		}
+		if err != nil {
+			if stderr.Len() > 0 {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			return "", fmt.Errorf("token command %q failed: %w", command, err)
+		}
+		return string(token), nil
+	}}
+}
+
+// NewSignerAuth authenticates by signing the requests with a SSH key, like from a private key file
+func NewSignerAuth(signer ssh.Signer) Authenticator {
+	return NewMultiKeyAuth(signer)
+}
+
+// NewMultiKeyAuth signs the requests with all given SSH keys, the server picks the one it authorized
+func NewMultiKeyAuth(signers ...ssh.Signer) Authenticator {
+	return signerAuth(signers)
+}
+
+// NewAgentAuth signs the requests with all usable keys of the SSH agent. If keyFilters are given,
+// only the keys matching one of them by SHA256 fingerprint (like "SHA256:...") or by comment are used.
+func NewAgentAuth(sshAgent agent.Agent, keyFilters ...string) (Authenticator, error) {
+	signers, err := agentSigners(sshAgent, keyFilters)
+	if err != nil {
+		return nil, err
+	}
+	if len(signers) == 0 {
+		return nil, errors.New("no usable SSH agent key found")
+	}
+
+	return NewMultiKeyAuth(signers...), nil
+}
+
+// NoAuth sends the requests without any authentication headers
+func NoAuth() Authenticator {
+	return noAuth{}
+}
+
+type tokenAuth []byte
+
+func (t tokenAuth) Authenticate(header http.Header, timestamp int64, body []byte) error {
+	header.Set("X-SecurityToken", calcSecurityToken(t, timestamp, body))
+	header.Set("X-Application", calcAppID(t))
+	return nil
+}
+
+// preparedAuthenticator is implemented by Authenticators fetching their credentials, like by a token command.
+// prepare() is called before the request is signed, so the request can be canceled while waiting for them.
+type preparedAuthenticator interface {
+	prepare(ctx context.Context) error
+}
+
+// cachedTokenAuth fetches the token on the first request and again after Invalidate(). Concurrent
+// requests share one fetch, which runs without holding the lock, so they can give up waiting for it.
+type cachedTokenAuth struct {
+	fetch func(ctx context.Context) (string, error)
+
+	mu    sync.Mutex
+	token tokenAuth
+	// the running fetch, nil if there is none
+	fetching *tokenFetch
+}
+
+type tokenFetch struct {
+	done  chan struct{}
+	token tokenAuth
+	err   error
+}
+
+func (a *cachedTokenAuth) prepare(ctx context.Context) error {
+	_, err := a.getToken(ctx)
+	return err
+}
+
+func (a *cachedTokenAuth) Authenticate(header http.Header, timestamp int64, body []byte) error {
+	token, err := a.getToken(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return token.Authenticate(header, timestamp, body)
+}
+
+// getToken returns the cached token, or waits for the fetch until the context is done
+func (a *cachedTokenAuth) getToken(ctx context.Context) (tokenAuth, error) {
+	a.mu.Lock()
+	token := a.token
+	fetch := a.fetching
+	if token == nil && fetch == nil {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		a.fetching = fetch
+		go a.runFetch(fetch)
+	}
+	a.mu.Unlock()
+
+	if token != nil {
+		return token, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-fetch.done:
+		return fetch.token, fetch.err
+	}
+}
+
+// runFetch fetches the token independent of the context of the request starting it, as other requests
+// might wait for it as well. Errors aren't cached, so the next request tries again.
+func (a *cachedTokenAuth) runFetch(fetch *tokenFetch) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+	defer cancel()
+
+	token, err := a.fetch(ctx)
+	token = strings.TrimSpace(token)
+	if err == nil && token == "" {
+		err = errors.New("got an empty token")
+	}
+
+	a.mu.Lock()
+	if err == nil {
+		fetch.token = tokenAuth(token)
+		a.token = fetch.token
+	}
+	fetch.err = err
+	a.fetching = nil
+	a.mu.Unlock()
+
+	close(fetch.done)
+}
+
+func (a *cachedTokenAuth) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.token = nil
+}
+
+// signerAuth signs with a private key or all SSH agent keys, like the Python adminapi does
+type signerAuth []ssh.Signer
+
+func (s signerAuth) Authenticate(header http.Header, timestamp int64, body []byte) error {
+	publicKeys, signatures, err := calcSignatures(s, calcMessage(timestamp, body))
+	if err != nil {
+		return err
+	}
+
+	header.Set("X-PublicKeys", publicKeys)
+	header.Set("X-Signatures", signatures)
+	return nil
+}
+
+type noAuth struct{}
+
+func (noAuth) Authenticate(http.Header, int64, []byte) error {
+	return nil
+}
+
+// calcSignatures signs the message with every signer and returns the comma separated public keys and signatures
+func calcSignatures(signers []ssh.Signer, message []byte) (string, string, error) {
+	publicKeys := make([]string, len(signers))
+	signatures := make([]string, len(signers))
+	for idx, signer := range signers {
+		signature, err := signer.Sign(rand.Reader, message)
+		if err != nil {
+			return "", "", err
+		}
+		publicKeys[idx] = base64.StdEncoding.EncodeToString(signer.PublicKey().Marshal())
+		signatures[idx] = base64.StdEncoding.EncodeToString(ssh.Marshal(signature))
+	}
+
+	return strings.Join(publicKeys, ","), strings.Join(signatures, ","), nil
+}
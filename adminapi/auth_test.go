@@ -0,0 +1,158 @@
+package adminapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer returns a SA mock which only accepts requests authenticated with the given token
+func newTokenServer(t *testing.T, validToken string) (*httptest.Server, *int) {
+	t.Helper()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		req, _ := io.ReadAll(r.Body)
+
+		if r.Header.Get("X-SecurityToken") != calcSecurityToken([]byte(validToken), timestamp, req) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success", "result": []}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestTokenCommandAuth(t *testing.T) {
+	server, requests := newTokenServer(t, "new-token")
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	runsFile := filepath.Join(dir, "runs")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("old-token\n"), 0o600))
+
+	command := "echo run >> " + runsFile + " && cat " + tokenFile
+	client, err := NewClient(WithBaseURL(server.URL), WithTokenCommand(command))
+	require.NoError(t, err)
+	runs := func() int {
+		content, _ := os.ReadFile(runsFile)
+		return strings.Count(string(content), "run")
+	}
+
+	// the helper is asked again once after the token got rejected
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.EqualError(t, err, "HTTP error 401 Unauthorized")
+	assert.Equal(t, 2, *requests)
+	assert.Equal(t, 2, runs())
+
+	// the rotated token is fetched after the cached one got rejected
+	require.NoError(t, os.WriteFile(tokenFile, []byte("new-token\n"), 0o600))
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 4, *requests)
+	assert.Equal(t, 3, runs())
+
+	// the token is cached as long as it's accepted
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 5, *requests)
+	assert.Equal(t, 3, runs())
+
+	client, err = NewClient(WithBaseURL(server.URL), WithTokenCommand("echo broken >&2; exit 3"))
+	require.NoError(t, err)
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.EqualError(t, err, `failed to authenticate request: token command "echo broken >&2; exit 3" failed: exit status 3: broken`)
+
+	client, err = NewClient(WithBaseURL(server.URL), WithTokenCommand("true"))
+	require.NoError(t, err)
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.EqualError(t, err, "failed to authenticate request: got an empty token")
+}
+
+func TestTokenCommandTimeout(t *testing.T) {
+	server, requests := newTokenServer(t, "token")
+
+	defer func(timeout time.Duration) { tokenFetchTimeout = timeout }(tokenFetchTimeout)
+	tokenFetchTimeout = 500 * time.Millisecond
+
+	client, err := NewClient(WithBaseURL(server.URL), WithTokenCommand("sleep 10; echo token"))
+	require.NoError(t, err)
+
+	// requests waiting for the hanging helper give up when their context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.CountContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+
+	// ...and the helper itself is killed after tokenFetchTimeout
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.EqualError(t, err, `failed to authenticate request: token command "sleep 10; echo token" failed: `+
+		"no token after 500ms: context deadline exceeded")
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Zero(t, *requests)
+}
+
+func TestTokenFileAuth(t *testing.T) {
+	server, requests := newTokenServer(t, "file-token")
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+
+	client, err := NewClient(WithBaseURL(server.URL), WithTokenFile(tokenFile))
+	require.NoError(t, err)
+
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 1, *requests)
+
+	client, err = NewClient(WithBaseURL(server.URL), WithTokenFile(filepath.Join(t.TempDir(), "nope")))
+	require.NoError(t, err)
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.ErrorContains(t, err, "failed to authenticate request: failed to read token file")
+}
+
+func TestTokenSettings(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SERVERADMIN_BASE_URL", "https://serveradmin.example.com")
+
+	_, err := loadConfig("")
+	require.ErrorContains(t, err, "no authentication method found")
+
+	t.Setenv("SERVERADMIN_TOKEN_COMMAND", "pass show serveradmin")
+	cfg, err := loadConfig("")
+	require.NoError(t, err)
+	assert.IsType(t, &cachedTokenAuth{}, cfg.authenticator)
+
+	// a literal token takes precedence
+	t.Setenv("SERVERADMIN_TOKEN", "literal")
+	cfg, err = loadConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, NewTokenAuth("literal"), cfg.authenticator)
+}
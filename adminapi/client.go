@@ -0,0 +1,218 @@
+package adminapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Client talks to one SA instance using one set of credentials. Multiple clients can be used
+// in parallel to access different instances or to use different credentials.
+type Client struct {
+	config
+	httpClient  *http.Client
+	userAgent   string
+	retryPolicy RetryPolicy
+
+	passphraseFunc PassphraseFunc
+
+	// the last measured difference to the clock of SA, and the one applied to the timestamps
+	clockSkew   atomic.Int64
+	clockOffset atomic.Int64
+}
+
+// Option configures a Client
+type Option func(*Client) error
+
+// getDefaultClient returns the client used by the package level functions like NewQuery(),
+// which is configured by environment variables. Loading config only once.
+var getDefaultClient = sync.OnceValues(func() (*Client, error) {
+	return NewClientFromEnv()
+})
+
+// NewClient creates a client which is only configured by the given options
+func NewClient(options ...Option) (*Client, error) {
+	return newClient(config{apiVersion: version}, options)
+}
+
+// NewClientFromEnv creates a client configured by the SERVERADMIN_* environment variables and
+// the config file, using the profile set in SERVERADMIN_PROFILE. The given options take precedence
+// over the environment. To select a profile in code, use NewClient(WithProfile(...)) instead.
+func NewClientFromEnv(options ...Option) (*Client, error) {
+	cfg, err := loadConfig(os.Getenv("SERVERADMIN_PROFILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(cfg, options)
+}
+
+func newClient(cfg config, options []Option) (*Client, error) {
+	client := &Client{
+		config:      cfg,
+		httpClient:  http.DefaultClient,
+		userAgent:   userAgent,
+		retryPolicy: DefaultRetryPolicy(),
+	}
+	for _, option := range options {
+		if err := option(client); err != nil {
+			return nil, err
+		}
+	}
+
+	if client.baseURL == "" {
+		return nil, errors.New("no base URL configured")
+	}
+
+	if client.encryptedKey != nil {
+		signer, err := client.encryptedKey.signer(client.passphraseFunc)
+		if err != nil {
+			return nil, err
+		}
+		client.authenticator = NewSignerAuth(signer)
+		client.encryptedKey = nil
+	}
+	if client.authenticator == nil {
+		client.authenticator = NoAuth()
+	}
+
+	return client, nil
+}
+
+// WithProfile configures the client like NewClientFromEnv() does, but using the given profile of the
+// config file, like "staging" for the [staging] section. Options given before are overridden.
+func WithProfile(profile string) Option {
+	return func(c *Client) error {
+		cfg, err := loadConfig(profile)
+		if err != nil {
+			return err
+		}
+		c.config = cfg
+		return nil
+	}
+}
+
+// WithBaseURL sets the URL of the SA instance, like "https://serveradmin.example.com"
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		c.baseURL = strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/api")
+		return nil
+	}
+}
+
+// WithAuthenticator sets how the requests are authenticated, like with one of the built-in schemes
+// NewTokenAuth(), NewSignerAuth(), NewAgentAuth() or a custom implementation
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(c *Client) error {
+		c.authenticator = authenticator
+		c.encryptedKey = nil
+		return nil
+	}
+}
+
+// WithToken authenticates with a security token
+func WithToken(token string) Option {
+	return WithAuthenticator(NewTokenAuth(token))
+}
+
+// WithTokenFile authenticates with the security token stored in the file, see NewTokenFileAuth()
+func WithTokenFile(path string) Option {
+	return WithAuthenticator(NewTokenFileAuth(path))
+}
+
+// WithTokenCommand authenticates with the security token printed by the command, see NewTokenCommandAuth()
+func WithTokenCommand(command string) Option {
+	return WithAuthenticator(NewTokenCommandAuth(command))
+}
+
+// WithSigner authenticates by signing the requests with a SSH key, like from a private key file or SSH agent
+func WithSigner(signer ssh.Signer) Option {
+	return WithAuthenticator(NewSignerAuth(signer))
+}
+
+// WithSigners signs the requests with all given SSH keys, the server picks the one it authorized
+func WithSigners(signers ...ssh.Signer) Option {
+	return WithAuthenticator(NewMultiKeyAuth(signers...))
+}
+
+// WithSSHAgent signs the requests with all usable keys of the SSH agent. If keyFilters are given,
+// only the keys matching one of them by SHA256 fingerprint (like "SHA256:...") or by comment are used.
+func WithSSHAgent(sshAgent agent.Agent, keyFilters ...string) Option {
+	return func(c *Client) error {
+		authenticator, err := NewAgentAuth(sshAgent, keyFilters...)
+		if err != nil {
+			return err
+		}
+		return WithAuthenticator(authenticator)(c)
+	}
+}
+
+// WithPassphraseFunc sets the callback asked for the passphrase of an encrypted private key set by key_path or
+// SERVERADMIN_KEY_PATH, when neither SERVERADMIN_KEY_PASSPHRASE nor SERVERADMIN_KEY_PASSPHRASE_FILE is set
+func WithPassphraseFunc(passphraseFunc PassphraseFunc) Option {
+	return func(c *Client) error {
+		c.passphraseFunc = passphraseFunc
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send the requests, http.DefaultClient by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithUserAgent overrides the User-Agent header sent with every request
+func WithUserAgent(agent string) Option {
+	return func(c *Client) error {
+		c.userAgent = agent
+		return nil
+	}
+}
+
+// WithRetryPolicy sets how requests failing with transient errors are retried, see DefaultRetryPolicy()
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		c.retryPolicy = policy
+		return nil
+	}
+}
+
+// NewQuery initialize a new query which loads data from SA via this client if needed
+func (c *Client) NewQuery(filters Filters) Query {
+	query := NewQuery(filters)
+	query.client = c
+
+	return query
+}
+
+// FromQuery creates a new Query object from a query string, which loads data via this client
+func (c *Client) FromQuery(query string) (Query, error) {
+	q, err := FromQuery(query)
+	q.client = c
+
+	return q, err
+}
+
+// resolveClient returns the given client, or the default client configured by environment variables if it's nil
+func resolveClient(client *Client) (*Client, error) {
+	if client != nil {
+		return client, nil
+	}
+
+	client, err := getDefaultClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	return client, nil
+}
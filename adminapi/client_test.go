@@ -0,0 +1,186 @@
+package adminapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(WithToken("1234567890"))
+	require.EqualError(t, err, "no base URL configured")
+
+	client, err := NewClient(WithBaseURL("https://serveradmin.example.com/api/"), WithToken("1234567890"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin.example.com", client.baseURL)
+	assert.Equal(t, NewTokenAuth("1234567890"), client.authenticator)
+	assert.Equal(t, userAgent, client.userAgent)
+	assert.Equal(t, http.DefaultClient, client.httpClient)
+
+	keyBytes, err := os.ReadFile("testdata/test.key")
+	require.NoError(t, err)
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	require.NoError(t, err)
+
+	httpClient := &http.Client{}
+	client, err = NewClient(
+		WithBaseURL("https://serveradmin.example.com"),
+		WithToken("1234567890"),
+		WithSigner(signer),
+		WithHTTPClient(httpClient),
+		WithUserAgent("my-tool"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, NewSignerAuth(signer), client.authenticator)
+	assert.Same(t, httpClient, client.httpClient)
+	assert.Equal(t, "my-tool", client.userAgent)
+}
+
+func TestMultipleClients(t *testing.T) {
+	newServer := func(hostname string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+			req, _ := io.ReadAll(r.Body)
+			assert.Equal(t, calcSecurityToken([]byte(hostname), timestamp, req), r.Header.Get("X-SecurityToken"))
+			assert.Equal(t, "my-tool", r.Header.Get("User-Agent"))
+
+			w.WriteHeader(200)
+			switch r.URL.Path {
+			case apiEndpointQuery:
+				_, _ = w.Write([]byte(`{"status": "success", "result": [{"object_id": 1, "hostname": "` + hostname + `"}]}`))
+			case apiEndpointCommit:
+				_, _ = w.Write([]byte(`{"status": "success"}`))
+			}
+		}))
+	}
+	production := newServer("production")
+	defer production.Close()
+	staging := newServer("staging")
+	defer staging.Close()
+
+	// no env variables needed when using explicit clients
+	os.Clearenv()
+	resetConfig()
+
+	for _, server := range []struct {
+		url      string
+		hostname string
+	}{
+		{production.URL, "production"},
+		{staging.URL, "staging"},
+	} {
+		client, err := NewClient(WithBaseURL(server.url), WithToken(server.hostname), WithUserAgent("my-tool"))
+		require.NoError(t, err)
+
+		query, err := client.FromQuery("hostname=foo")
+		require.NoError(t, err)
+		object, err := query.One()
+		require.NoError(t, err)
+		assert.Equal(t, server.hostname, object.Get("hostname"))
+
+		// the object remembers the client which loaded it
+		require.NoError(t, object.Set("hostname", "bar"))
+		require.NoError(t, object.Commit())
+	}
+}
+
+func TestMultiKeySignatures(t *testing.T) {
+	keyring, fingerprints := newTestKeyring(t, "first", "second", "third")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		req, _ := io.ReadAll(r.Body)
+
+		publicKeys := strings.Split(r.Header.Get("X-PublicKeys"), ",")
+		signatures := strings.Split(r.Header.Get("X-Signatures"), ",")
+		require.Len(t, signatures, len(publicKeys))
+
+		var verified []string
+		for idx := range publicKeys {
+			publicKey, signature := parseSignatureHeaders(t, publicKeys[idx], signatures[idx])
+			require.NoError(t, publicKey.Verify(calcMessage(timestamp, req), signature))
+			verified = append(verified, ssh.FingerprintSHA256(publicKey))
+		}
+		assert.Equal(t, []string{fingerprints[0], fingerprints[2]}, verified)
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success", "result": []}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL), WithSSHAgent(keyring, "first", fingerprints[2]))
+	require.NoError(t, err)
+
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	count, err := query.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = NewClient(WithBaseURL(server.URL), WithSSHAgent(agent.NewKeyring()))
+	require.EqualError(t, err, "no usable SSH agent key found")
+}
+
+func parseSignatureHeaders(t *testing.T, publicKey, signature string) (ssh.PublicKey, *ssh.Signature) {
+	t.Helper()
+
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	require.NoError(t, err)
+	parsedKey, err := ssh.ParsePublicKey(keyBytes)
+	require.NoError(t, err)
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	parsedSignature := &ssh.Signature{}
+	require.NoError(t, ssh.Unmarshal(signatureBytes, parsedSignature))
+
+	return parsedKey, parsedSignature
+}
+
+func TestCustomAuthenticator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "broker:"+r.Header.Get("X-Timestamp")+":"+string(req), r.Header.Get("X-Broker-Token"))
+		assert.Empty(t, r.Header.Get("X-SecurityToken"))
+		assert.Empty(t, r.Header.Get("X-Signatures"))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success", "result": []}`))
+	}))
+	defer server.Close()
+
+	broker := AuthenticatorFunc(func(header http.Header, timestamp int64, body []byte) error {
+		header.Set("X-Broker-Token", "broker:"+strconv.FormatInt(timestamp, 10)+":"+string(body))
+		return nil
+	})
+	client, err := NewClient(WithBaseURL(server.URL), WithToken("1234567890"), WithAuthenticator(broker))
+	require.NoError(t, err)
+
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.NoError(t, err)
+
+	failing := AuthenticatorFunc(func(http.Header, int64, []byte) error {
+		return errors.New("broker unavailable")
+	})
+	client, err = NewClient(WithBaseURL(server.URL), WithAuthenticator(failing), WithRetryPolicy(NoRetries()))
+	require.NoError(t, err)
+
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.ErrorContains(t, err, "failed to authenticate request: broker unavailable")
+
+	client, err = NewClient(WithBaseURL(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, NoAuth(), client.authenticator)
+}
@@ -0,0 +1,44 @@
+package adminapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// maxClockSkew is the difference to the clock of SA which is tolerated before the timestamps of the
+// requests are corrected. The Date header has a resolution of one second, smaller differences are noise.
+const maxClockSkew = 5 * time.Second
+
+// ClockSkew returns how far the clock of SA was ahead of the local clock at the last response, negative
+// if it's behind. It's measured by the Date header of every response and 0 before the first request.
+func (c *Client) ClockSkew() time.Duration {
+	return time.Duration(c.clockSkew.Load())
+}
+
+// now returns the time used for the X-Timestamp header, corrected by the clock skew if SA rejected a request
+func (c *Client) now() time.Time {
+	return time.Now().Add(time.Duration(c.clockOffset.Load()))
+}
+
+// measureClockSkew compares the Date header of the response with the local clock
+func (c *Client) measureClockSkew(resp *http.Response) {
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	c.clockSkew.Store(int64(time.Until(serverTime).Round(time.Second)))
+}
+
+// resyncClock corrects the timestamps of further requests by the measured clock skew, if SA rejected
+// the request and the timestamp was likely the reason
+func (c *Client) resyncClock(err error) bool {
+	skew := c.ClockSkew()
+	offset := time.Duration(c.clockOffset.Load())
+	if !isRejected(err) || (skew-offset).Abs() <= maxClockSkew {
+		return false
+	}
+
+	c.clockOffset.Store(int64(skew))
+	return true
+}
@@ -0,0 +1,57 @@
+package adminapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSkew(t *testing.T) {
+	var requests int
+	serverOffset := time.Hour
+	rejectAll := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		serverNow := time.Now().Add(serverOffset)
+		w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+
+		// SA only accepts requests signed within a grace period
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		if rejectAll || serverNow.Sub(time.Unix(timestamp, 0)).Abs() > 16*time.Second {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success", "result": []}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL), WithToken("1234567890"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), client.ClockSkew())
+
+	// the rejected request is signed again with the corrected timestamp
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.InDelta(t, time.Hour, client.ClockSkew(), float64(2*time.Second))
+
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests)
+
+	// rejections for other reasons than the timestamp aren't retried
+	requests = 0
+	rejectAll = true
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.Count()
+	require.EqualError(t, err, "HTTP error 403 Forbidden")
+	assert.Equal(t, 1, requests)
+}
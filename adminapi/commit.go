@@ -0,0 +1,371 @@
+package adminapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// like {"created": [], "changed": [{"object_id": 483903, "backup_disabled": {"action": "update", "old": false, "new": true}}], "deleted": []}
+type commitRequest struct {
+	Created []map[string]any `json:"created"`
+	Changed []map[string]any `json:"changed"`
+	Deleted []int            `json:"deleted"`
+}
+
+// like {"status": "success", "created": [{"object_id": 483904, "hostname": "foo.local"}]}
+// or {"status": "error", "type": "CommitNewerData", "message": "...", "newer": [[483903, "state", "offline"]]}
+type commitResponse struct {
+	Status  string `json:"status"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	// the created objects including their new object_id, in the order of the request
+	Created []map[string]any `json:"created"`
+	// object_id, attribute and current value of all attributes which were changed concurrently
+	Newer [][]any `json:"newer"`
+}
+
+// ConflictError is returned by a commit when an attribute was modified by someone else
+// after it was loaded. The object has to be reloaded before the change can be applied.
+type ConflictError struct {
+	ObjectID  int
+	Attribute string
+	// Value is the current value of the attribute in SA
+	Value any
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict: attribute %s of object %d was modified concurrently (now: %v)", e.Attribute, e.ObjectID, e.Value)
+}
+
+// CommitAction is the kind of modification a commit applies to an object
+type CommitAction string
+
+const (
+	CommitActionCreated CommitAction = "created"
+	CommitActionChanged CommitAction = "changed"
+	CommitActionDeleted CommitAction = "deleted"
+)
+
+// CommitResult reports what a commit did with a single object
+type CommitResult struct {
+	ObjectID int
+	Action   CommitAction
+	// Applied is true when SA accepted the commit. SA applies a commit atomically,
+	// so either all or none of the objects of one commit are applied.
+	Applied bool
+}
+
+func newCommitRequest() commitRequest {
+	return commitRequest{
+		Created: []map[string]any{},
+		Changed: []map[string]any{},
+		Deleted: []int{},
+	}
+}
+
+// Set changes the value of an attribute. The change is only sent to SA when calling Commit().
+func (s *ServerObject) Set(attribute string, value any) error {
+	if attribute == "object_id" {
+		return errors.New("object_id can't be changed")
+	}
+
+	current, err := s.lookup(attribute)
+	if err != nil {
+		return err
+	}
+
+	// multi attributes are always stored as []any, so changes can be compared value by value
+	if _, isMulti := current.([]any); isMulti {
+		values, isSlice := toAnySlice(value)
+		if !isSlice {
+			return fmt.Errorf("attribute %s is a multi attribute, got %T", attribute, value)
+		}
+		value = values
+	}
+
+	if s.oldValues == nil {
+		s.oldValues = make(map[string]any)
+	}
+	// keep the value from the last commit, so multiple Set() calls result in one change
+	if _, changed := s.oldValues[attribute]; !changed {
+		s.oldValues[attribute] = current
+	}
+	s.attributes[attribute] = value
+
+	// setting the value from the last commit again is no change anymore
+	if isSameValue(s.oldValues[attribute], value) {
+		delete(s.oldValues, attribute)
+	}
+
+	return nil
+}
+
+// isSameValue compares single values like equalValues(), and multi values ignoring their order
+func isSameValue(oldValue, newValue any) bool {
+	if oldMulti, isMulti := oldValue.([]any); isMulti {
+		newMulti, _ := newValue.([]any)
+		return len(diffValues(newMulti, oldMulti)) == 0 && len(diffValues(oldMulti, newMulti)) == 0
+	}
+
+	return equalValues(oldValue, newValue)
+}
+
+// Delete marks the object to be deleted in SA with the next commit. The mark is shared with all
+// copies of the object, like the ones returned by Query.All() and Query.One().
+func (s *ServerObject) Delete() {
+	if s.state == nil {
+		s.state = &objectState{}
+	}
+	s.state.deleted = true
+}
+
+// isDeleted returns true if the object is marked to be deleted with the next commit
+func (s *ServerObject) isDeleted() bool {
+	return s.state != nil && s.state.deleted
+}
+
+// Change is a pending modification of a single attribute
+type Change struct {
+	Attribute string
+	Old       any
+	New       any
+}
+
+// HasChanges returns true if the object has pending changes which are not committed yet.
+// New objects and objects marked for deletion always have pending changes.
+func (s *ServerObject) HasChanges() bool {
+	if s.isNew() {
+		// deleting an object which was never created is a no-op
+		return !s.isDeleted()
+	}
+
+	return s.isDeleted() || len(s.oldValues) > 0
+}
+
+// Changes lists all modified attributes, sorted by attribute name
+func (s *ServerObject) Changes() []Change {
+	changes := make([]Change, 0, len(s.oldValues))
+	for _, attribute := range slices.Sorted(maps.Keys(s.oldValues)) {
+		changes = append(changes, Change{
+			Attribute: attribute,
+			Old:       normalizeValue(s.oldValues[attribute]),
+			New:       normalizeValue(s.attributes[attribute]),
+		})
+	}
+
+	return changes
+}
+
+// Rollback discards all pending changes of the object, including a pending deletion
+func (s *ServerObject) Rollback() {
+	maps.Copy(s.attributes, s.oldValues)
+	clear(s.oldValues)
+	if s.state != nil {
+		s.state.deleted = false
+	}
+}
+
+// Commit sends all pending changes of the object to SA
+func (s *ServerObject) Commit() error {
+	return s.CommitContext(context.Background())
+}
+
+// CommitContext sends all pending changes of the object to SA, the context is used for the request to SA
+func (s *ServerObject) CommitContext(ctx context.Context) error {
+	if !s.HasChanges() {
+		return nil
+	}
+
+	client, err := resolveClient(s.client)
+	if err != nil {
+		return err
+	}
+
+	request := newCommitRequest()
+	request.addObject(s)
+
+	commitResp, err := client.sendCommit(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	return confirmCommit([]*ServerObject{s}, commitResp)
+}
+
+// Commit sends the pending changes of all loaded objects to SA within one single commit
+func (q *Query) Commit() ([]CommitResult, error) {
+	return q.CommitContext(context.Background())
+}
+
+// CommitContext sends the pending changes of all loaded objects to SA, the context is used for the request to SA
+func (q *Query) CommitContext(ctx context.Context) ([]CommitResult, error) {
+	request := newCommitRequest()
+
+	var results []CommitResult
+	var objects []*ServerObject
+	for idx := range q.serverObjects {
+		object := &q.serverObjects[idx]
+		if !object.HasChanges() {
+			continue
+		}
+
+		results = append(results, CommitResult{
+			Action: request.addObject(object),
+		})
+		objects = append(objects, object)
+	}
+
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	client, err := resolveClient(q.client)
+	if err != nil {
+		return nil, err
+	}
+
+	commitResp, err := client.sendCommit(ctx, request)
+	if err == nil {
+		err = confirmCommit(objects, commitResp)
+	}
+
+	for idx, object := range objects {
+		// created objects know their object_id only after a successful commit
+		results[idx].ObjectID = object.ObjectID()
+		results[idx].Applied = err == nil
+	}
+
+	return results, err
+}
+
+// HasChanges returns true if any of the loaded objects has pending changes
+func (q *Query) HasChanges() bool {
+	return slices.ContainsFunc(q.serverObjects, func(object ServerObject) bool {
+		return object.HasChanges()
+	})
+}
+
+// Rollback discards the pending changes of all loaded objects
+func (q *Query) Rollback() {
+	for idx := range q.serverObjects {
+		q.serverObjects[idx].Rollback()
+	}
+}
+
+// addObject adds the pending changes of the object to the commit and returns the resulting action
+func (c *commitRequest) addObject(object *ServerObject) CommitAction {
+	if object.isNew() {
+		created := maps.Clone(object.attributes)
+		delete(created, "object_id")
+		c.Created = append(c.Created, created)
+		return CommitActionCreated
+	}
+
+	if object.isDeleted() {
+		// pending attribute changes don't matter anymore when the object is gone
+		c.Deleted = append(c.Deleted, object.ObjectID())
+		return CommitActionDeleted
+	}
+
+	c.Changed = append(c.Changed, object.changeSet())
+
+	return CommitActionChanged
+}
+
+// isNew returns true if the object was created via NewObject() and is not committed yet
+func (s *ServerObject) isNew() bool {
+	return s.state != nil && s.state.isNew
+}
+
+// changeSet builds the "changed" entry of the commit payload for this object
+func (s *ServerObject) changeSet() map[string]any {
+	changes := make(map[string]any, len(s.oldValues)+1)
+	changes["object_id"] = s.ObjectID()
+	for attribute, oldValue := range s.oldValues {
+		if oldMulti, isMulti := oldValue.([]any); isMulti {
+			newMulti, _ := s.attributes[attribute].([]any)
+			changes[attribute] = multiChange(oldMulti, newMulti)
+			continue
+		}
+		changes[attribute] = map[string]any{
+			"action": "update",
+			"old":    oldValue,
+			"new":    s.attributes[attribute],
+		}
+	}
+
+	return changes
+}
+
+// confirmChanges marks all pending changes as committed
+func (s *ServerObject) confirmChanges() {
+	clear(s.oldValues)
+	if s.state != nil {
+		s.state.deleted = false
+	}
+}
+
+// confirmCommit marks the changes of all committed objects as done and fills the
+// created objects with the attributes assigned by SA, like the object_id
+func confirmCommit(objects []*ServerObject, commitResp commitResponse) error {
+	created := commitResp.Created
+	for _, object := range objects {
+		if object.isNew() {
+			if len(created) == 0 {
+				return errors.New("commit response is missing the created objects")
+			}
+			maps.Copy(object.attributes, created[0])
+			created = created[1:]
+			object.state.isNew = false
+		}
+		object.confirmChanges()
+	}
+
+	return nil
+}
+
+func (c *Client) sendCommit(ctx context.Context, request commitRequest) (commitResponse, error) {
+	commitResp := commitResponse{}
+
+	resp, err := c.sendRequest(ctx, apiEndpointCommit, request)
+	if err != nil {
+		return commitResp, err
+	}
+	defer resp.Body.Close()
+
+	if err = decodeResponse(resp.Body, &commitResp); err != nil {
+		return commitResp, fmt.Errorf("failed to decode commit response: %w", err)
+	}
+
+	if commitResp.Type == "CommitNewerData" && len(commitResp.Newer) > 0 {
+		return commitResp, fmt.Errorf("commit failed: %s: %w", commitResp.Message, newConflictErrors(commitResp.Newer))
+	}
+	if commitResp.Status != "success" {
+		return commitResp, fmt.Errorf("commit failed: %s: %s", commitResp.Type, commitResp.Message)
+	}
+
+	return commitResp, nil
+}
+
+// newConflictErrors converts the "newer" entries of a commit response into joined ConflictErrors
+func newConflictErrors(newer [][]any) error {
+	conflicts := make([]error, 0, len(newer))
+	for _, entry := range newer {
+		conflict := &ConflictError{}
+		if len(entry) > 0 {
+			conflict.ObjectID, _ = normalizeValue(entry[0]).(int)
+		}
+		if len(entry) > 1 {
+			conflict.Attribute, _ = entry[1].(string)
+		}
+		if len(entry) > 2 {
+			conflict.Value = normalizeValue(entry[2])
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return errors.Join(conflicts...)
+}
@@ -0,0 +1,431 @@
+package adminapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	object := newServerObject(map[string]any{
+		"object_id": 483903.0,
+		"hostname":  "foo.bar.local",
+	})
+
+	require.NoError(t, object.Set("hostname", "bar.bar.local"))
+	require.NoError(t, object.Set("hostname", "baz.bar.local"))
+	assert.Equal(t, "baz.bar.local", object.Get("hostname"))
+	assert.Equal(t, map[string]any{"hostname": "foo.bar.local"}, object.oldValues)
+
+	require.EqualError(t, object.Set("object_id", 1), "object_id can't be changed")
+	require.EqualError(t, object.Set("nope", 1), "attribute nope is not loaded, add it via Query.SetAttributes()")
+}
+
+func TestCommit(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.URL.Path+" "+string(req))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success"}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	object := newServerObject(map[string]any{
+		"object_id":       483903.0,
+		"backup_disabled": false,
+	})
+
+	// nothing to commit -> no request at all
+	require.NoError(t, object.Commit())
+	assert.Empty(t, requests)
+
+	require.NoError(t, object.Set("backup_disabled", true))
+	require.NoError(t, object.Commit())
+	expectedRequest := `/api/dataset/commit {"created":[],"changed":[{"backup_disabled":{"action":"update","new":true,"old":false},"object_id":483903}],"deleted":[]}`
+	assert.Equal(t, []string{expectedRequest}, requests)
+	assert.Empty(t, object.oldValues)
+	assert.Equal(t, true, object.Get("backup_disabled"))
+
+	// changes are confirmed, so a second commit is a no-op
+	require.NoError(t, object.Commit())
+	assert.Len(t, requests, 1)
+}
+
+func TestCommitError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "error", "type": "ValidationError", "message": "Invalid value for backup_disabled"}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	object := newServerObject(map[string]any{
+		"object_id":       483903.0,
+		"backup_disabled": false,
+	})
+	require.NoError(t, object.Set("backup_disabled", "nope"))
+
+	err := object.Commit()
+	require.EqualError(t, err, "commit failed: ValidationError: Invalid value for backup_disabled")
+
+	// the change is still pending after a failed commit
+	assert.Equal(t, map[string]any{"backup_disabled": false}, object.oldValues)
+}
+
+func TestQueryCommit(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(req))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success"}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	query := NewQuery(Filters{"hostname": Regexp("foo.*")})
+	query.loaded = true
+	query.serverObjects = ServerObjects{
+		newServerObject(map[string]any{"object_id": 1.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 2.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 3.0, "state": "online"}),
+	}
+
+	servers, err := query.All()
+	require.NoError(t, err)
+	require.NoError(t, servers[0].Set("state", "maintenance"))
+	require.NoError(t, servers[2].Set("state", "maintenance"))
+
+	results, err := query.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, []CommitResult{
+		{ObjectID: 1, Action: CommitActionChanged, Applied: true},
+		{ObjectID: 3, Action: CommitActionChanged, Applied: true},
+	}, results)
+
+	expectedRequest := `{"created":[],"changed":[` +
+		`{"object_id":1,"state":{"action":"update","new":"maintenance","old":"online"}},` +
+		`{"object_id":3,"state":{"action":"update","new":"maintenance","old":"online"}}` +
+		`],"deleted":[]}`
+	assert.Equal(t, []string{expectedRequest}, requests)
+
+	// everything is committed now
+	results, err = query.Commit()
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Len(t, requests, 1)
+}
+
+func TestQueryDelete(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(req))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success"}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	query := NewQuery(Filters{"hostname": Regexp("foo.*")})
+	query.loaded = true
+	query.serverObjects = ServerObjects{
+		newServerObject(map[string]any{"object_id": 1.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 2.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 3.0, "state": "online"}),
+	}
+
+	// guard against deleting more than expected
+	err := query.Delete(2)
+	require.EqualError(t, err, "refusing to delete 3 server objects, expected at most 2")
+	assert.False(t, query.serverObjects[0].HasChanges())
+
+	require.NoError(t, query.serverObjects[0].Set("state", "retired"))
+	require.NoError(t, query.Delete(3))
+
+	results, err := query.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, []CommitResult{
+		{ObjectID: 1, Action: CommitActionDeleted, Applied: true},
+		{ObjectID: 2, Action: CommitActionDeleted, Applied: true},
+		{ObjectID: 3, Action: CommitActionDeleted, Applied: true},
+	}, results)
+	assert.Equal(t, []string{`{"created":[],"changed":[],"deleted":[1,2,3]}`}, requests)
+}
+
+func TestDeleteViaCopies(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(req))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success"}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	query := NewQuery(Filters{"hostname": Regexp("foo.*")})
+	query.loaded = true
+	query.serverObjects = ServerObjects{
+		newServerObject(map[string]any{"object_id": 1.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 2.0, "state": "online"}),
+	}
+
+	servers, err := query.All()
+	require.NoError(t, err)
+	for _, server := range servers {
+		server.Delete()
+	}
+	assert.True(t, query.HasChanges())
+
+	results, err := query.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, []CommitResult{
+		{ObjectID: 1, Action: CommitActionDeleted, Applied: true},
+		{ObjectID: 2, Action: CommitActionDeleted, Applied: true},
+	}, results)
+	assert.Equal(t, []string{`{"created":[],"changed":[],"deleted":[1,2]}`}, requests)
+	assert.False(t, query.HasChanges())
+
+	// same for the copy returned by One()
+	query = NewQuery(Filters{"hostname": "foo"})
+	query.loaded = true
+	query.serverObjects = ServerObjects{newServerObject(map[string]any{"object_id": 3.0})}
+	one, err := query.One()
+	require.NoError(t, err)
+	one.Delete()
+	assert.True(t, query.HasChanges())
+	query.Rollback()
+	assert.False(t, one.HasChanges())
+}
+
+func TestCommitMixed(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(req))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success"}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	query := NewQuery(Filters{"hostname": Regexp("foo.*")})
+	query.loaded = true
+	query.serverObjects = ServerObjects{
+		newServerObject(map[string]any{"object_id": 1.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 2.0, "state": "online"}),
+	}
+	require.NoError(t, query.serverObjects[0].Set("state", "maintenance"))
+	query.serverObjects[1].Delete()
+
+	results, err := query.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, []CommitResult{
+		{ObjectID: 1, Action: CommitActionChanged, Applied: true},
+		{ObjectID: 2, Action: CommitActionDeleted, Applied: true},
+	}, results)
+
+	expectedRequest := `{"created":[],"changed":[` +
+		`{"object_id":1,"state":{"action":"update","new":"maintenance","old":"online"}}` +
+		`],"deleted":[2]}`
+	assert.Equal(t, []string{expectedRequest}, requests)
+}
+
+func TestCreateObject(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.URL.String()+" "+string(req))
+
+		w.WriteHeader(200)
+		switch r.URL.Path {
+		case apiEndpointNewObject:
+			_, _ = w.Write([]byte(`{"object_id": null, "servertype": "vm", "hostname": null, "tags": []}`))
+		case apiEndpointCommit:
+			_, _ = w.Write([]byte(`{"status": "success", "created": [{"object_id": 483904, "servertype": "vm", "hostname": "new.local", "tags": ["web"]}]}`))
+		}
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	object, err := NewObject("vm")
+	require.NoError(t, err)
+	assert.Equal(t, 0, object.ObjectID())
+	assert.True(t, object.HasChanges())
+
+	require.NoError(t, object.Set("hostname", "new.local"))
+	tags, err := object.GetMulti("tags")
+	require.NoError(t, err)
+	tags.Add("web")
+
+	require.NoError(t, object.Commit())
+	assert.Equal(t, 483904, object.ObjectID())
+	assert.False(t, object.HasChanges())
+
+	expectedRequests := []string{
+		"/api/dataset/new_object?servertype=vm null",
+		`/api/dataset/commit {"created":[{"hostname":"new.local","servertype":"vm","tags":["web"]}],"changed":[],"deleted":[]}`,
+	}
+	assert.Equal(t, expectedRequests, requests)
+
+	// further changes are normal updates of the created object
+	require.NoError(t, object.Set("hostname", "renamed.local"))
+	assert.Equal(t, map[string]any{
+		"object_id": 483904,
+		"hostname":  map[string]any{"action": "update", "old": "new.local", "new": "renamed.local"},
+	}, object.changeSet())
+}
+
+func TestCommitConflict(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(req))
+
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "error", "type": "CommitNewerData", "message": "Newer data available", "newer": [[483903, "state", "offline"]]}`))
+	}))
+	defer server.Close()
+
+	resetConfig()
+	os.Clearenv()
+	_ = os.Setenv("SERVERADMIN_TOKEN", "1234567890")
+	_ = os.Setenv("SERVERADMIN_BASE_URL", server.URL)
+
+	object := newServerObject(map[string]any{
+		"object_id": 483903.0,
+		"state":     "online",
+	})
+	require.NoError(t, object.Set("state", "maintenance"))
+	require.NoError(t, object.Set("state", "retired"))
+
+	err := object.Commit()
+	require.EqualError(t, err, "commit failed: Newer data available: conflict: attribute state of object 483903 was modified concurrently (now: offline)")
+
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, &ConflictError{ObjectID: 483903, Attribute: "state", Value: "offline"}, conflict)
+
+	// the value as loaded is sent as "old", not the intermediate one
+	expectedRequest := `{"created":[],"changed":[{"object_id":483903,"state":{"action":"update","new":"retired","old":"online"}}],"deleted":[]}`
+	assert.Equal(t, []string{expectedRequest}, requests)
+	assert.True(t, object.HasChanges())
+}
+
+func TestObjectsWithoutObjectID(t *testing.T) {
+	// only objects from NewObject() are created, not the ones missing an object_id
+	var object ServerObject
+	assert.False(t, object.HasChanges())
+	require.NoError(t, object.Commit())
+
+	object = NewServerObject(map[string]any{"hostname": "foo.local"})
+	assert.False(t, object.HasChanges())
+	require.NoError(t, object.Commit())
+}
+
+func TestChangesAndRollback(t *testing.T) {
+	object := newServerObject(map[string]any{
+		"object_id": 483903.0,
+		"state":     "online",
+		"memory":    1024.0,
+		"tags":      []any{"web"},
+	})
+	assert.False(t, object.HasChanges())
+	assert.Empty(t, object.Changes())
+
+	require.NoError(t, object.Set("state", "maintenance"))
+	require.NoError(t, object.Set("memory", 2048))
+	tags, err := object.GetMulti("tags")
+	require.NoError(t, err)
+	tags.Add("backup")
+
+	assert.True(t, object.HasChanges())
+	assert.Equal(t, []Change{
+		{Attribute: "memory", Old: 1024, New: 2048},
+		{Attribute: "state", Old: "online", New: "maintenance"},
+		{Attribute: "tags", Old: []any{"web"}, New: []any{"web", "backup"}},
+	}, object.Changes())
+
+	// changing the values back is no change anymore
+	require.NoError(t, object.Set("memory", 1024))
+	tags.Remove("backup")
+	tags.Add("backup")
+	tags.Remove("backup")
+	assert.Equal(t, []Change{
+		{Attribute: "state", Old: "online", New: "maintenance"},
+	}, object.Changes())
+	assert.Equal(t, map[string]any{
+		"object_id": 483903,
+		"state":     map[string]any{"action": "update", "old": "online", "new": "maintenance"},
+	}, object.changeSet())
+	require.NoError(t, object.Set("state", "online"))
+	assert.False(t, object.HasChanges())
+
+	require.NoError(t, object.Set("state", "maintenance"))
+	object.Delete()
+	object.Rollback()
+	assert.False(t, object.HasChanges())
+	assert.Empty(t, object.Changes())
+	assert.Equal(t, "online", object.Get("state"))
+	assert.Equal(t, 1024, object.Get("memory"))
+	assert.Equal(t, []any{"web"}, object.Get("tags"))
+}
+
+func TestQueryRollback(t *testing.T) {
+	query := NewQuery(Filters{"hostname": Regexp("foo.*")})
+	query.loaded = true
+	query.serverObjects = ServerObjects{
+		newServerObject(map[string]any{"object_id": 1.0, "state": "online"}),
+		newServerObject(map[string]any{"object_id": 2.0, "state": "online"}),
+	}
+	assert.False(t, query.HasChanges())
+
+	require.NoError(t, query.serverObjects[0].Set("state", "maintenance"))
+	query.serverObjects[1].Delete()
+	assert.True(t, query.HasChanges())
+
+	query.Rollback()
+	assert.False(t, query.HasChanges())
+	assert.Equal(t, "online", query.serverObjects[0].Get("state"))
+}
@@ -2,12 +2,14 @@ package adminapi
 
 import (
 	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"slices"
 	"strings"
-	"sync"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
@@ -19,62 +21,233 @@ const (
 )
 
 type config struct {
-	baseURL    string
-	apiVersion string
-	authToken  []byte
-	sshSigner  ssh.Signer
+	baseURL       string
+	apiVersion    string
+	authenticator Authenticator
+	// set if the private key of key_path needs a passphrase which isn't configured
+	encryptedKey *encryptedKey
 }
 
-// getConfig returns the configuration for the API client. Loading config only once
-var getConfig = sync.OnceValues(loadConfig)
+// PassphraseFunc returns the passphrase of the encrypted private key at keyPath, like by prompting the user
+type PassphraseFunc func(keyPath string) ([]byte, error)
 
-// loadConfig returns the configuration for the API client
-var loadConfig = func() (config, error) {
+// encryptedKey is a private key which is decrypted by the PassphraseFunc of the client
+type encryptedKey struct {
+	path     string
+	keyType  string
+	keyBytes []byte
+}
+
+// loadConfig returns the configuration for the API client, loaded from the config file and env vars.
+// The profile selects a section of the config file, "" uses only the settings at the top of the file.
+var loadConfig = func(profile string) (config, error) {
 	cfg := config{
 		apiVersion: version,
 	}
 
-	baseURL := os.Getenv("SERVERADMIN_BASE_URL")
+	settings, ownCredentials, err := loadSettings(profile)
+	if err != nil {
+		return cfg, err
+	}
+
+	baseURL := settings["base_url"]
 	if baseURL == "" {
-		return cfg, errors.New("env var SERVERADMIN_BASE_URL not set")
+		return cfg, errors.New("no base URL configured: set env var SERVERADMIN_BASE_URL or base_url in ~/.adminapirc")
 	}
-	cfg.baseURL = strings.TrimRight(baseURL, "/api")
+	cfg.baseURL = strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/api")
 
-	if privateKeyPath, ok := os.LookupEnv("SERVERADMIN_KEY_PATH"); ok {
+	if privateKeyPath, ok := settings["key_path"]; ok {
 		keyBytes, err := os.ReadFile(privateKeyPath)
 		if err != nil {
 			return cfg, fmt.Errorf("failed to read private key from %s: %w", privateKeyPath, err)
 		}
-		signer, err := ssh.ParsePrivateKey(keyBytes)
+		passphrase, err := settingsPassphrase(settings)
 		if err != nil {
-			return cfg, fmt.Errorf("failed to parse private key: %w", err)
+			return cfg, err
 		}
-		cfg.sshSigner = signer
-	} else if authSock, ok := os.LookupEnv("SSH_AUTH_SOCK"); ok && authSock != "" {
+		signer, err := parsePrivateKey(privateKeyPath, keyBytes, passphrase)
+		var missingErr *ssh.PassphraseMissingError
+		if errors.As(err, &missingErr) {
+			cfg.encryptedKey = &encryptedKey{
+				path:     privateKeyPath,
+				keyType:  keyType(keyBytes, missingErr),
+				keyBytes: keyBytes,
+			}
+		} else if err != nil {
+			return cfg, err
+		} else {
+			cfg.authenticator = NewSignerAuth(signer)
+		}
+	} else if authSock, ok := os.LookupEnv("SSH_AUTH_SOCK"); ok && authSock != "" && useAgent(settings, ownCredentials) {
 		sock, err := net.Dial("unix", authSock)
 		if err != nil {
 			return cfg, fmt.Errorf("failed to connect to SSH agent: %w", err)
 		}
-		signers, err := agent.NewClient(sock).Signers()
+		signers, err := agentSigners(agent.NewClient(sock), splitList(settings["agent_key"]))
 		if err != nil {
-			return cfg, fmt.Errorf("failed to get SSH agent signers: %w", err)
+			return cfg, err
 		}
-		for _, signer := range signers {
-			_, err := signer.Sign(rand.Reader, []byte("test"))
-			if err == nil {
-				cfg.sshSigner = signer
-				break
-			}
+		if len(signers) > 0 {
+			cfg.authenticator = NewMultiKeyAuth(signers...)
 		}
 	}
 
-	if cfg.sshSigner == nil {
-		cfg.authToken = []byte(os.Getenv("SERVERADMIN_TOKEN"))
+	if cfg.authenticator == nil && cfg.encryptedKey == nil {
+		cfg.authenticator = tokenAuthenticator(settings)
 	}
 
-	if len(cfg.authToken) == 0 && cfg.sshSigner == nil {
-		return cfg, errors.New("no authentication method found: set SERVERADMIN_TOKEN/SERVERADMIN_KEY_PATH/SSH_AUTH_SOCK")
+	if cfg.authenticator == nil && cfg.encryptedKey == nil {
+		return cfg, errors.New(
+			"no authentication method found: set SERVERADMIN_TOKEN/SERVERADMIN_TOKEN_FILE/" +
+				"SERVERADMIN_TOKEN_COMMAND/SERVERADMIN_KEY_PATH/SSH_AUTH_SOCK",
+		)
 	}
 
 	return cfg, nil
 }
+
+// useAgent returns false if a profile configured its own token, which then takes precedence over the SSH agent
+func useAgent(settings configSection, ownCredentials bool) bool {
+	_, agentKey := settings["agent_key"]
+	return !ownCredentials || agentKey
+}
+
+// tokenAuthenticator returns the Authenticator for the token set literally, in a file or by a command, nil if none is set
+func tokenAuthenticator(settings configSection) Authenticator {
+	switch {
+	case settings["auth_token"] != "":
+		return NewTokenAuth(settings["auth_token"])
+	case settings["auth_token_file"] != "":
+		return NewTokenFileAuth(settings["auth_token_file"])
+	case settings["auth_token_command"] != "":
+		return NewTokenCommandAuth(settings["auth_token_command"])
+	default:
+		return nil
+	}
+}
+
+// settingsPassphrase returns the private key passphrase from key_passphrase or the file in key_passphrase_file,
+// nil if none is configured
+func settingsPassphrase(settings configSection) ([]byte, error) {
+	if passphrase, ok := settings["key_passphrase"]; ok {
+		return []byte(passphrase), nil
+	}
+
+	passphraseFile, ok := settings["key_passphrase_file"]
+	if !ok {
+		return nil, nil
+	}
+	passphrase, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key passphrase from %s: %w", passphraseFile, err)
+	}
+
+	return []byte(strings.TrimRight(string(passphrase), "\r\n")), nil
+}
+
+// parsePrivateKey parses an unencrypted or encrypted OpenSSH/PEM private key. The passphrase is only
+// used for encrypted keys, if it's nil they fail with a *ssh.PassphraseMissingError.
+func parsePrivateKey(path string, keyBytes []byte, passphrase []byte) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(err, &missingErr) {
+		if passphrase == nil {
+			return nil, missingErr
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, passphrase)
+	}
+
+	switch {
+	case errors.Is(err, x509.IncorrectPasswordError):
+		return nil, fmt.Errorf("wrong passphrase for %s private key %s", keyType(keyBytes, missingErr), path)
+	case err != nil:
+		return nil, fmt.Errorf("failed to parse %s private key %s: %w", keyType(keyBytes, missingErr), path, err)
+	}
+
+	return signer, nil
+}
+
+// keyType describes the type of private key for error messages, like "ssh-ed25519" or "RSA PRIVATE KEY"
+func keyType(keyBytes []byte, missingErr *ssh.PassphraseMissingError) string {
+	// encrypted OpenSSH keys contain the public key in plain text
+	if missingErr != nil && missingErr.PublicKey != nil {
+		return missingErr.PublicKey.Type()
+	}
+
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return "non-PEM"
+	}
+
+	return block.Type
+}
+
+// signer decrypts the key with the passphrase returned by passphraseFunc
+func (k *encryptedKey) signer(passphraseFunc PassphraseFunc) (ssh.Signer, error) {
+	if passphraseFunc == nil {
+		return nil, fmt.Errorf(
+			"%s private key %s is encrypted: set SERVERADMIN_KEY_PASSPHRASE or SERVERADMIN_KEY_PASSPHRASE_FILE",
+			k.keyType, k.path,
+		)
+	}
+
+	passphrase, err := passphraseFunc(k.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passphrase for private key %s: %w", k.path, err)
+	}
+
+	return parsePrivateKey(k.path, k.keyBytes, passphrase)
+}
+
+// agentSigners returns all usable keys of the SSH agent. If keyFilters are given, only the keys
+// matching one of them by SHA256 fingerprint (like "SHA256:...") or by comment are used.
+func agentSigners(sshAgent agent.Agent, keyFilters []string) ([]ssh.Signer, error) {
+	signers, err := sshAgent.Signers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSH agent signers: %w", err)
+	}
+
+	var comments map[string]string
+	if len(keyFilters) > 0 {
+		keys, err := sshAgent.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SSH agent keys: %w", err)
+		}
+		comments = make(map[string]string, len(keys))
+		for _, key := range keys {
+			comments[ssh.FingerprintSHA256(key)] = key.Comment
+		}
+	}
+
+	var usable []ssh.Signer
+	for _, signer := range signers {
+		if len(keyFilters) > 0 {
+			fingerprint := ssh.FingerprintSHA256(signer.PublicKey())
+			if !slices.Contains(keyFilters, fingerprint) && !slices.Contains(keyFilters, comments[fingerprint]) {
+				continue
+			}
+		}
+
+		_, err := signer.Sign(rand.Reader, []byte("test"))
+		if err == nil {
+			usable = append(usable, signer)
+		}
+	}
+
+	if len(keyFilters) > 0 && len(usable) == 0 {
+		return nil, fmt.Errorf("no usable SSH agent key matches %s", strings.Join(keyFilters, ", "))
+	}
+
+	return usable, nil
+}
+
+// splitList splits a comma separated setting into its trimmed, non-empty values
+func splitList(value string) []string {
+	var values []string
+	for _, val := range strings.Split(value, ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			values = append(values, val)
+		}
+	}
+	return values
+}
@@ -1,12 +1,17 @@
 package adminapi
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -14,7 +19,7 @@ func TestLoadConfig(t *testing.T) {
 	os.Clearenv()
 
 	// make a test without SERVERADMIN_BASE_URL set
-	_, err := loadConfig()
+	_, err := loadConfig("")
 	require.Error(t, err, "env var SERVERADMIN_BASE_URL not set")
 
 	// spawn mocked serveradmin server
@@ -24,26 +29,92 @@ func TestLoadConfig(t *testing.T) {
 
 	t.Run("load static token", func(t *testing.T) {
 		_ = os.Setenv("SERVERADMIN_TOKEN", "jolo")
-		cfg, err := loadConfig()
+		cfg, err := loadConfig("")
 
 		require.NoError(t, err)
-		assert.Nil(t, cfg.sshSigner)
-		assert.Equal(t, "jolo", string(cfg.authToken))
+		assert.Equal(t, NewTokenAuth("jolo"), cfg.authenticator)
 	})
 
 	t.Run("load valid private key", func(t *testing.T) {
 		_ = os.Setenv("SERVERADMIN_KEY_PATH", "testdata/test.key")
-		cfg, err := loadConfig()
+		cfg, err := loadConfig("")
 
 		require.NoError(t, err)
-		assert.NotNil(t, cfg)
-		assert.Empty(t, cfg.authToken)
+		assert.IsType(t, signerAuth{}, cfg.authenticator)
 	})
 
 	t.Run("load invalid private Key", func(t *testing.T) {
 		_ = os.Setenv("SERVERADMIN_KEY_PATH", "testdata/nope.key")
-		_, err := loadConfig()
+		_, err := loadConfig("")
 
 		assert.Error(t, err, "failed to read private key from testdata/nope.key: open testdata/nope.key: no such file or directory")
 	})
 }
+
+func TestEncryptedPrivateKey(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SERVERADMIN_BASE_URL", "https://serveradmin.example.com")
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("secret"))
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600))
+	t.Setenv("SERVERADMIN_KEY_PATH", keyPath)
+
+	t.Run("missing passphrase", func(t *testing.T) {
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.Nil(t, cfg.authenticator)
+
+		_, err = NewClientFromEnv()
+		require.EqualError(t, err, "ssh-ed25519 private key "+keyPath+
+			" is encrypted: set SERVERADMIN_KEY_PASSPHRASE or SERVERADMIN_KEY_PASSPHRASE_FILE")
+	})
+
+	t.Run("passphrase from env", func(t *testing.T) {
+		t.Setenv("SERVERADMIN_KEY_PASSPHRASE", "secret")
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.IsType(t, signerAuth{}, cfg.authenticator)
+		assert.Nil(t, cfg.encryptedKey)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		t.Setenv("SERVERADMIN_KEY_PASSPHRASE", "wrong")
+		_, err := loadConfig("")
+		require.EqualError(t, err, "wrong passphrase for ssh-ed25519 private key "+keyPath)
+	})
+
+	t.Run("passphrase file", func(t *testing.T) {
+		passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+		require.NoError(t, os.WriteFile(passphraseFile, []byte("secret\n"), 0o600))
+		t.Setenv("SERVERADMIN_KEY_PASSPHRASE_FILE", passphraseFile)
+
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.IsType(t, signerAuth{}, cfg.authenticator)
+	})
+
+	t.Run("passphrase callback", func(t *testing.T) {
+		client, err := NewClientFromEnv(WithPassphraseFunc(func(path string) ([]byte, error) {
+			assert.Equal(t, keyPath, path)
+			return []byte("secret"), nil
+		}))
+		require.NoError(t, err)
+		assert.IsType(t, signerAuth{}, client.authenticator)
+		assert.Nil(t, client.encryptedKey)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		invalidPath := filepath.Join(t.TempDir(), "invalid")
+		require.NoError(t, os.WriteFile(invalidPath, []byte("no key"), 0o600))
+		t.Setenv("SERVERADMIN_KEY_PATH", invalidPath)
+
+		_, err := loadConfig("")
+		require.ErrorContains(t, err, "failed to parse non-PEM private key "+invalidPath)
+	})
+}
@@ -0,0 +1,164 @@
+package adminapi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultConfigFile is the config file in the home directory, shared with the Python adminapi
+const defaultConfigFile = ".adminapirc"
+
+// envSettings maps the keys of the config file to the env vars which take precedence over them
+var envSettings = map[string]string{
+	"base_url":   "SERVERADMIN_BASE_URL",
+	"auth_token": "SERVERADMIN_TOKEN",
+	"key_path":   "SERVERADMIN_KEY_PATH",
+	"agent_key":  "SERVERADMIN_AGENT_KEY",
+
+	"key_passphrase":      "SERVERADMIN_KEY_PASSPHRASE",
+	"key_passphrase_file": "SERVERADMIN_KEY_PASSPHRASE_FILE",
+	"auth_token_file":     "SERVERADMIN_TOKEN_FILE",
+	"auth_token_command":  "SERVERADMIN_TOKEN_COMMAND",
+}
+
+// tokenSettings are the alternative sources of the auth token, setting one of them by env var replaces the others
+var tokenSettings = []string{"auth_token", "auth_token_file", "auth_token_command"}
+
+// credentialSettings are the keys selecting how requests are authenticated. A profile setting any of them
+// only uses its own credentials, without the authSettings from the top of the file or from env vars.
+var credentialSettings = []string{"auth_token", "auth_token_file", "auth_token_command", "key_path", "agent_key"}
+
+// authSettings are the credentialSettings and the settings belonging to them
+var authSettings = append([]string{"key_passphrase", "key_passphrase_file"}, credentialSettings...)
+
+// configSection is the set of "key = value" pairs of one section of the config file
+type configSection map[string]string
+
+// loadSettings merges the settings from the config file with the env vars. Env vars override the keys at the
+// top of the file, while the keys of the given profile section override both, "" selects no profile.
+// ownCredentials is true if the profile sets its own credentials.
+func loadSettings(profile string) (settings configSection, ownCredentials bool, err error) {
+	sections, err := readConfigFile()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var profileSettings configSection
+	if profile != "" {
+		var ok bool
+		profileSettings, ok = sections[profile]
+		if !ok {
+			return nil, false, fmt.Errorf("profile %s not found in config file", profile)
+		}
+		ownCredentials = slices.ContainsFunc(credentialSettings, func(key string) bool {
+			_, ok := profileSettings[key]
+			return ok
+		})
+	}
+
+	settings = configSection{}
+	maps.Copy(settings, sections[""])
+	envValues := configSection{}
+	for key, envName := range envSettings {
+		if value, ok := os.LookupEnv(envName); ok {
+			envValues[key] = value
+		}
+	}
+	// a token from the env replaces the one from the file, even if the file has a different kind of source
+	if slices.ContainsFunc(tokenSettings, func(key string) bool {
+		_, ok := envValues[key]
+		return ok
+	}) {
+		for _, key := range tokenSettings {
+			delete(settings, key)
+		}
+	}
+	maps.Copy(settings, envValues)
+	if ownCredentials {
+		for _, key := range authSettings {
+			delete(settings, key)
+		}
+	}
+	// the profile wins, so a SERVERADMIN_BASE_URL left in the shell doesn't send its queries elsewhere
+	maps.Copy(settings, profileSettings)
+
+	return settings, ownCredentials, nil
+}
+
+// readConfigFile reads the config file from SERVERADMIN_CONFIG or ~/.adminapirc. A missing
+// ~/.adminapirc is fine, while an explicitly configured file has to exist.
+func readConfigFile() (map[string]configSection, error) {
+	path, explicit := os.LookupEnv("SERVERADMIN_CONFIG")
+	if !explicit {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil //nolint:nilerr // without home directory there is just no config file
+		}
+		path = filepath.Join(home, defaultConfigFile)
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	sections, err := parseConfigFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return sections, nil
+}
+
+// parseConfigFile parses an INI like file with "key = value" lines. Empty lines and lines starting
+// with # or ; are ignored. Keys before the first [section] header belong to the section "".
+func parseConfigFile(r io.Reader) (map[string]configSection, error) {
+	sections := map[string]configSection{"": {}}
+	current := sections[""]
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[' && line[len(line)-1] == ']':
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if sections[name] == nil {
+				sections[name] = configSection{}
+			}
+			current = sections[name]
+		default:
+			key, value, found := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !found || key == "" {
+				return nil, fmt.Errorf("line %d: expected key = value, got %s", lineNumber, line)
+			}
+			current[key] = unquote(strings.TrimSpace(value))
+		}
+	}
+
+	return sections, scanner.Err()
+}
+
+// unquote removes matching single or double quotes around a value
+func unquote(value string) string {
+	if l := len(value); l >= 2 && (value[0] == '"' || value[0] == '\'') && value[l-1] == value[0] {
+		return value[1 : l-1]
+	}
+	return value
+}
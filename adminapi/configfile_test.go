@@ -0,0 +1,262 @@
+package adminapi
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	content := `
+# shared with the python adminapi
+base_url = https://serveradmin.example.com
+auth_token="secret token"
+; another comment
+
+[staging]
+base_url = 'https://serveradmin-staging.example.com'
+`
+	sections, err := parseConfigFile(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]configSection{
+		"": {
+			"base_url":   "https://serveradmin.example.com",
+			"auth_token": "secret token",
+		},
+		"staging": {
+			"base_url": "https://serveradmin-staging.example.com",
+		},
+	}, sections)
+
+	_, err = parseConfigFile(strings.NewReader("base_url = foo\ninvalid line"))
+	require.EqualError(t, err, "line 2: expected key = value, got invalid line")
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	os.Clearenv()
+
+	configFile := filepath.Join(t.TempDir(), "adminapirc")
+	err := os.WriteFile(configFile, []byte("base_url = https://serveradmin.example.com/api\nauth_token = from-file\n"), 0o600)
+	require.NoError(t, err)
+
+	t.Run("missing explicit config file", func(t *testing.T) {
+		t.Setenv("SERVERADMIN_CONFIG", filepath.Join(t.TempDir(), "nope"))
+		_, err := loadConfig("")
+		require.ErrorContains(t, err, "failed to open config file")
+	})
+
+	t.Run("missing default config file", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		_, err := loadConfig("")
+		require.EqualError(t, err, "no base URL configured: set env var SERVERADMIN_BASE_URL or base_url in ~/.adminapirc")
+	})
+
+	t.Run("default config file in home", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".adminapirc"), []byte("base_url=https://home.example.com\nauth_token=home\n"), 0o600))
+
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, "https://home.example.com", cfg.baseURL)
+		assert.Equal(t, NewTokenAuth("home"), cfg.authenticator)
+	})
+
+	t.Run("settings from config file", func(t *testing.T) {
+		t.Setenv("SERVERADMIN_CONFIG", configFile)
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, "https://serveradmin.example.com", cfg.baseURL)
+		assert.Equal(t, NewTokenAuth("from-file"), cfg.authenticator)
+	})
+
+	t.Run("env vars override the config file", func(t *testing.T) {
+		t.Setenv("SERVERADMIN_CONFIG", configFile)
+		t.Setenv("SERVERADMIN_TOKEN", "from-env")
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, "https://serveradmin.example.com", cfg.baseURL)
+		assert.Equal(t, NewTokenAuth("from-env"), cfg.authenticator)
+	})
+
+	t.Run("env token command overrides the token of the config file", func(t *testing.T) {
+		t.Setenv("SERVERADMIN_CONFIG", configFile)
+		t.Setenv("SERVERADMIN_TOKEN_COMMAND", "echo from-command")
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		assert.IsType(t, &cachedTokenAuth{}, cfg.authenticator)
+	})
+}
+
+func TestAgentSigners(t *testing.T) {
+	keyring, fingerprints := newTestKeyring(t, "first", "second")
+
+	signers, err := agentSigners(keyring, nil)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprints, signerFingerprints(signers))
+
+	signers, err = agentSigners(keyring, []string{"second"})
+	require.NoError(t, err)
+	assert.Equal(t, fingerprints[1:], signerFingerprints(signers))
+
+	signers, err = agentSigners(keyring, []string{fingerprints[1], "first"})
+	require.NoError(t, err)
+	assert.Equal(t, fingerprints, signerFingerprints(signers))
+
+	_, err = agentSigners(keyring, []string{"third", "fourth"})
+	require.EqualError(t, err, "no usable SSH agent key matches third, fourth")
+
+	assert.Equal(t, []string{"first", "SHA256:abc"}, splitList(" first, ,SHA256:abc,"))
+}
+
+// newTestKeyring returns an in-memory SSH agent with one new key per comment
+func newTestKeyring(t *testing.T, comments ...string) (agent.Agent, []string) {
+	t.Helper()
+
+	keyring := agent.NewKeyring()
+	fingerprints := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: privateKey, Comment: comment}))
+
+		signer, err := ssh.NewSignerFromKey(privateKey)
+		require.NoError(t, err)
+		fingerprints = append(fingerprints, ssh.FingerprintSHA256(signer.PublicKey()))
+	}
+
+	return keyring, fingerprints
+}
+
+// serveAgent serves the agent on a unix socket and returns its path, like SSH_AUTH_SOCK
+func serveAgent(t *testing.T, keyring agent.Agent) string {
+	t.Helper()
+
+	// short path, as unix socket paths are limited to ~100 characters
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	listener, err := net.Listen("unix", filepath.Join(dir, "sock"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func signerFingerprints(signers []ssh.Signer) []string {
+	fingerprints := make([]string, len(signers))
+	for idx, signer := range signers {
+		fingerprints[idx] = ssh.FingerprintSHA256(signer.PublicKey())
+	}
+	return fingerprints
+}
+
+func TestProfiles(t *testing.T) {
+	os.Clearenv()
+
+	configFile := filepath.Join(t.TempDir(), "adminapirc")
+	content := `
+auth_token = shared-token
+
+[production]
+base_url = https://serveradmin.example.com
+
+[staging]
+base_url = https://serveradmin-staging.example.com
+auth_token = staging-token
+`
+	require.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+	t.Setenv("SERVERADMIN_CONFIG", configFile)
+
+	cfg, err := loadConfig("production")
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin.example.com", cfg.baseURL)
+	assert.Equal(t, NewTokenAuth("shared-token"), cfg.authenticator)
+
+	cfg, err = loadConfig("staging")
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin-staging.example.com", cfg.baseURL)
+	assert.Equal(t, NewTokenAuth("staging-token"), cfg.authenticator)
+
+	_, err = loadConfig("nope")
+	require.EqualError(t, err, "profile nope not found in config file")
+
+	// without profile, there is no base_url
+	_, err = NewClientFromEnv()
+	require.Error(t, err)
+
+	t.Setenv("SERVERADMIN_PROFILE", "staging")
+	client, err := NewClientFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin-staging.example.com", client.baseURL)
+
+	client, err = NewClient(WithProfile("production"), WithUserAgent("my-tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin.example.com", client.baseURL)
+	assert.Equal(t, "my-tool", client.userAgent)
+}
+
+func TestProfileCredentials(t *testing.T) {
+	os.Clearenv()
+
+	configFile := filepath.Join(t.TempDir(), "adminapirc")
+	content := `
+base_url = https://serveradmin.example.com
+key_path = testdata/test.key
+
+[production]
+
+[staging]
+base_url = https://serveradmin-staging.example.com
+auth_token = staging-token
+`
+	require.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+	t.Setenv("SERVERADMIN_CONFIG", configFile)
+
+	cfg, err := loadConfig("production")
+	require.NoError(t, err)
+	assert.IsType(t, signerAuth{}, cfg.authenticator)
+
+	// the credentials of the profile replace the ones from the top of the file
+	cfg, err = loadConfig("staging")
+	require.NoError(t, err)
+	assert.Equal(t, NewTokenAuth("staging-token"), cfg.authenticator)
+
+	// ...and the ones from env vars and the SSH agent
+	keyring, _ := newTestKeyring(t, "agent key")
+	t.Setenv("SSH_AUTH_SOCK", serveAgent(t, keyring))
+	t.Setenv("SERVERADMIN_KEY_PATH", "testdata/test.key")
+	t.Setenv("SERVERADMIN_BASE_URL", "https://serveradmin-other.example.com")
+	cfg, err = loadConfig("staging")
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin-staging.example.com", cfg.baseURL)
+	assert.Equal(t, NewTokenAuth("staging-token"), cfg.authenticator)
+
+	// env vars still override the top of the file
+	cfg, err = loadConfig("production")
+	require.NoError(t, err)
+	assert.Equal(t, "https://serveradmin-other.example.com", cfg.baseURL)
+}
@@ -0,0 +1,299 @@
+package adminapi
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"time"
+)
+
+// tagName is the struct tag which maps a field to a SA attribute, like `sa:"hostname"`
+const tagName = "sa"
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	addrType   = reflect.TypeFor[netip.Addr]()
+	prefixType = reflect.TypeFor[netip.Prefix]()
+)
+
+// Decode fills the struct pointed to by target with the attributes of the object. Only exported
+// fields with a `sa:"attribute"` tag are filled, all tagged attributes have to be loaded.
+//
+// Supported field types are strings, bools, all int/uint/float types, time.Time, netip.Addr,
+// netip.Prefix, any, slices of them for multi attributes and pointers to them for nullable attributes.
+func (s ServerObject) Decode(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a pointer to a struct, got %T", target)
+	}
+
+	return s.decodeStruct(rv.Elem())
+}
+
+// DecodeAll fills the slice pointed to by target with one decoded struct per object, see ServerObject.Decode()
+func (s ServerObjects) DecodeAll(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("decode target must be a pointer to a slice, got %T", target)
+	}
+
+	sliceType := rv.Elem().Type()
+	elemType := sliceType.Elem()
+	isPointer := elemType.Kind() == reflect.Pointer
+	if isPointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a slice of structs, got %T", target)
+	}
+
+	result := reflect.MakeSlice(sliceType, len(s), len(s))
+	for idx, object := range s {
+		elem := reflect.New(elemType)
+		if err := object.decodeStruct(elem.Elem()); err != nil {
+			return fmt.Errorf("object %d: %w", object.ObjectID(), err)
+		}
+		if isPointer {
+			result.Index(idx).Set(elem)
+		} else {
+			result.Index(idx).Set(elem.Elem())
+		}
+	}
+	rv.Elem().Set(result)
+
+	return nil
+}
+
+// Encode sets all attributes of the `sa` tagged fields of source which differ from the current values
+// of the object, like Set() would do. The changes are only sent to SA when calling Commit().
+// The object_id is never changed.
+func (s *ServerObject) Encode(source any) error {
+	rv := reflect.Indirect(reflect.ValueOf(source))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("encode source must be a struct, got %T", source)
+	}
+
+	for _, field := range taggedFields(rv.Type()) {
+		if field.attribute == "object_id" {
+			continue
+		}
+
+		current, err := s.lookup(field.attribute)
+		if err != nil {
+			return err
+		}
+
+		fieldValue := rv.Field(field.index)
+		currentValue := reflect.New(fieldValue.Type()).Elem()
+		if decodeValue(current, currentValue) == nil && sameValue(currentValue, fieldValue) {
+			continue
+		}
+
+		if err = s.Set(field.attribute, encodeValue(fieldValue, timeLayout(current))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type taggedField struct {
+	index     int
+	name      string
+	attribute string
+}
+
+// taggedFields returns all exported fields of the struct type which have a `sa` tag
+func taggedFields(structType reflect.Type) []taggedField {
+	var fields []taggedField
+	for idx := range structType.NumField() {
+		field := structType.Field(idx)
+		attribute := field.Tag.Get(tagName)
+		if !field.IsExported() || attribute == "" || attribute == "-" {
+			continue
+		}
+		fields = append(fields, taggedField{index: idx, name: field.Name, attribute: attribute})
+	}
+
+	return fields
+}
+
+func (s ServerObject) decodeStruct(rv reflect.Value) error {
+	for _, field := range taggedFields(rv.Type()) {
+		val, err := s.lookup(field.attribute)
+		if err != nil {
+			return err
+		}
+		if err = decodeValue(val, rv.Field(field.index)); err != nil {
+			return fmt.Errorf("attribute %s into field %s: %w", field.attribute, field.name, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeValue converts a raw attribute value into the type of target
+func decodeValue(val any, target reflect.Value) error {
+	// empty attributes result in the zero value, like nil for pointers
+	if val == nil {
+		target.SetZero()
+		return nil
+	}
+
+	var converted any
+	var ok bool
+	switch target.Type() {
+	case timeType:
+		converted, ok = toTime(val)
+	case addrType:
+		converted, ok = toAddr(val)
+	case prefixType:
+		converted, ok = toPrefix(val)
+	default:
+		return decodeKind(val, target)
+	}
+	if !ok {
+		return conversionError(val, target)
+	}
+	target.Set(reflect.ValueOf(converted))
+
+	return nil
+}
+
+func decodeKind(val any, target reflect.Value) error {
+	switch target.Kind() { //nolint:exhaustive // all other kinds are not supported
+	case reflect.Pointer:
+		elem := reflect.New(target.Type().Elem())
+		if err := decodeValue(val, elem.Elem()); err != nil {
+			return err
+		}
+		target.Set(elem)
+	case reflect.Interface:
+		normalized := reflect.ValueOf(normalizeValue(val))
+		if !normalized.Type().AssignableTo(target.Type()) {
+			return conversionError(val, target)
+		}
+		target.Set(normalized)
+	case reflect.String:
+		strVal, ok := val.(string)
+		if !ok {
+			return conversionError(val, target)
+		}
+		target.SetString(strVal)
+	case reflect.Bool:
+		boolVal, ok := val.(bool)
+		if !ok {
+			return conversionError(val, target)
+		}
+		target.SetBool(boolVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, ok := toInt(val)
+		if !ok || target.OverflowInt(int64(intVal)) {
+			return conversionError(val, target)
+		}
+		target.SetInt(int64(intVal))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		intVal, ok := toInt(val)
+		if !ok || intVal < 0 || target.OverflowUint(uint64(intVal)) {
+			return conversionError(val, target)
+		}
+		target.SetUint(uint64(intVal))
+	case reflect.Float32, reflect.Float64:
+		floatVal, ok := toFloat(val)
+		if !ok || target.OverflowFloat(floatVal) {
+			return conversionError(val, target)
+		}
+		target.SetFloat(floatVal)
+	case reflect.Slice:
+		values, ok := val.([]any)
+		if !ok {
+			return conversionError(val, target)
+		}
+		slice := reflect.MakeSlice(target.Type(), len(values), len(values))
+		for idx, value := range values {
+			if err := decodeValue(value, slice.Index(idx)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", target.Type())
+	}
+
+	return nil
+}
+
+// timeLayout returns the layout of the current time value of an attribute, so dates stay dates.
+// RFC3339 is used for new values and values in other formats.
+func timeLayout(current any) string {
+	if values, isMulti := current.([]any); isMulti && len(values) > 0 {
+		current = values[0]
+	}
+	if str, ok := current.(string); ok {
+		// RFC3339 times match the first layout RFC3339Nano, they keep the default
+		for _, layout := range timeLayouts[1:] {
+			if _, err := time.Parse(layout, str); err == nil {
+				return layout
+			}
+		}
+	}
+
+	return time.RFC3339
+}
+
+// encodeValue converts a field value into the representation SA expects for the attribute,
+// times are formatted with the layout
+func encodeValue(rv reflect.Value, layout string) any {
+	switch typed := rv.Interface().(type) {
+	case time.Time:
+		if typed.IsZero() {
+			return nil
+		}
+		return typed.Format(layout)
+	case netip.Addr:
+		if !typed.IsValid() {
+			return nil
+		}
+		return typed.String()
+	case netip.Prefix:
+		if !typed.IsValid() {
+			return nil
+		}
+		return typed.String()
+	}
+
+	switch rv.Kind() { //nolint:exhaustive // all other kinds are used as they are
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return encodeValue(rv.Elem(), layout)
+	case reflect.Slice:
+		values := make([]any, rv.Len())
+		for idx := range values {
+			values[idx] = encodeValue(rv.Index(idx), layout)
+		}
+		return values
+	default:
+		return rv.Interface()
+	}
+}
+
+// sameValue compares two field values: times by instant and slices as sets, like SA does
+func sameValue(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice {
+		aValues, _ := encodeValue(a, time.RFC3339).([]any)
+		bValues, _ := encodeValue(b, time.RFC3339).([]any)
+		return len(aValues) == len(bValues) &&
+			len(diffValues(aValues, bValues)) == 0 &&
+			len(diffValues(bValues, aValues)) == 0
+	}
+
+	return equalValues(encodeValue(a, time.RFC3339), encodeValue(b, time.RFC3339)) ||
+		(a.Type() == timeType && a.Interface().(time.Time).Equal(b.Interface().(time.Time)))
+}
+
+func conversionError(val any, target reflect.Value) error {
+	val = normalizeValue(val)
+	return fmt.Errorf("can't convert %v (%T) to %s", val, val, target.Type())
+}
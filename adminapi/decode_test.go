@@ -0,0 +1,141 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testVM struct {
+	ObjectID  int          `sa:"object_id"`
+	Hostname  string       `sa:"hostname"`
+	Memory    uint32       `sa:"memory"`
+	Load      float64      `sa:"load"`
+	Disabled  bool         `sa:"backup_disabled"`
+	Created   time.Time    `sa:"created"`
+	IP        netip.Addr   `sa:"intern_ip"`
+	Network   netip.Prefix `sa:"network"`
+	Tags      []string     `sa:"tags"`
+	Owner     *string      `sa:"owner"`
+	Raw       any          `sa:"raw"`
+	Untagged  string
+	Ignored   string `sa:"-"`
+	unexposed string `sa:"hostname"` //nolint:unused // only used to test unexported fields
+}
+
+func newTestVMObject(objectID int, hostname string) ServerObject {
+	return newServerObject(map[string]any{
+		"object_id":       json.Number(fmt.Sprint(objectID)),
+		"hostname":        hostname,
+		"memory":          json.Number("2048"),
+		"load":            json.Number("2.5"),
+		"backup_disabled": true,
+		"created":         "2024-01-02T10:11:12Z",
+		"intern_ip":       "10.0.0.1",
+		"network":         "10.0.0.0/24",
+		"tags":            []any{"web", "prod"},
+		"owner":           nil,
+		"raw":             json.Number("42"),
+	})
+}
+
+func TestDecode(t *testing.T) {
+	object := newTestVMObject(483903, "foo.bar.local")
+
+	var vm testVM
+	require.NoError(t, object.Decode(&vm))
+	assert.Equal(t, testVM{
+		ObjectID: 483903,
+		Hostname: "foo.bar.local",
+		Memory:   2048,
+		Load:     2.5,
+		Disabled: true,
+		Created:  time.Date(2024, 1, 2, 10, 11, 12, 0, time.UTC),
+		IP:       netip.MustParseAddr("10.0.0.1"),
+		Network:  netip.MustParsePrefix("10.0.0.0/24"),
+		Tags:     []string{"web", "prod"},
+		Raw:      42,
+	}, vm)
+
+	require.EqualError(t, object.Decode(vm), "decode target must be a pointer to a struct, got adminapi.testVM")
+
+	var wrongType struct {
+		Hostname int `sa:"hostname"`
+	}
+	err := object.Decode(&wrongType)
+	require.EqualError(t, err, "attribute hostname into field Hostname: can't convert foo.bar.local (string) to int")
+
+	var overflow struct {
+		Memory int8 `sa:"memory"`
+	}
+	err = object.Decode(&overflow)
+	require.EqualError(t, err, "attribute memory into field Memory: can't convert 2048 (int) to int8")
+
+	var notLoaded struct {
+		State string `sa:"state"`
+	}
+	err = object.Decode(&notLoaded)
+	require.EqualError(t, err, "attribute state is not loaded, add it via Query.SetAttributes()")
+}
+
+func TestDecodeAll(t *testing.T) {
+	objects := ServerObjects{
+		newTestVMObject(1, "foo.bar.local"),
+		newTestVMObject(2, "bar.bar.local"),
+	}
+
+	var vms []testVM
+	require.NoError(t, objects.DecodeAll(&vms))
+	require.Len(t, vms, 2)
+	assert.Equal(t, "foo.bar.local", vms[0].Hostname)
+	assert.Equal(t, 2, vms[1].ObjectID)
+
+	var vmPointers []*testVM
+	require.NoError(t, objects.DecodeAll(&vmPointers))
+	require.Len(t, vmPointers, 2)
+	assert.Equal(t, "bar.bar.local", vmPointers[1].Hostname)
+
+	var hostnames []string
+	require.EqualError(t, objects.DecodeAll(&hostnames), "decode target must be a slice of structs, got *[]string")
+}
+
+func TestEncode(t *testing.T) {
+	object := newTestVMObject(483903, "foo.bar.local")
+
+	var vm testVM
+	require.NoError(t, object.Decode(&vm))
+
+	// nothing changed -> nothing to commit
+	require.NoError(t, object.Encode(vm))
+	assert.False(t, object.HasChanges())
+
+	owner := "team-a"
+	vm.ObjectID = 1
+	vm.Memory = 4096
+	vm.Created = vm.Created.In(time.FixedZone("CET", 3600))
+	vm.Tags = []string{"prod", "backup"}
+	vm.Owner = &owner
+	require.NoError(t, object.Encode(&vm))
+
+	assert.Equal(t, []Change{
+		{Attribute: "memory", Old: 2048, New: uint32(4096)},
+		{Attribute: "owner", Old: nil, New: "team-a"},
+		{Attribute: "tags", Old: []any{"web", "prod"}, New: []any{"prod", "backup"}},
+	}, object.Changes())
+	assert.Equal(t, 483903, object.ObjectID())
+
+	// dates keep their layout
+	object = newServerObject(map[string]any{"object_id": json.Number("1"), "created": "2025-01-31"})
+	var dates struct {
+		Created time.Time `sa:"created"`
+	}
+	require.NoError(t, object.Decode(&dates))
+	dates.Created = dates.Created.AddDate(0, 0, 1)
+	require.NoError(t, object.Encode(&dates))
+	assert.Equal(t, "2025-02-01", object.Get("created"))
+}
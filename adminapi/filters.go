@@ -1,5 +1,10 @@
 package adminapi
 
+import (
+	"net/netip"
+	"time"
+)
+
 // todo have proper values and more fitting types instead of any
 
 type (
@@ -8,7 +13,22 @@ type (
 )
 
 type value interface {
-	int | string | bool
+	int | float32 | float64 | string | bool | netip.Addr | netip.Prefix | time.Time
+}
+
+// orderedValue are the values which can be compared by GreaterThan() and friends, strings have to be dates like "2025-01-31"
+type orderedValue interface {
+	int | float32 | float64 | string | time.Time
+}
+
+// networkValue are IPs and networks, or strings for substring checks
+type networkValue interface {
+	netip.Addr | netip.Prefix | string
+}
+
+// prefixValue is a network as netip.Prefix or as string like "10.0.0.0/8"
+type prefixValue interface {
+	netip.Prefix | string
 }
 type valueOrFilter interface {
 	value | Filter
@@ -52,6 +72,51 @@ func Empty() Filter {
 	return createFilter("Empty", nil)
 }
 
+// StartsWith matches strings with the given prefix
+func StartsWith(prefix string) Filter {
+	return createFilter("StartsWith", prefix)
+}
+
+// GreaterThan matches numbers and times greater than the value
+func GreaterThan[V orderedValue](value V) Filter {
+	return createFilter("GreaterThan", value)
+}
+
+// GreaterThanOrEquals matches numbers and times greater than or equal to the value
+func GreaterThanOrEquals[V orderedValue](value V) Filter {
+	return createFilter("GreaterThanOrEquals", value)
+}
+
+// LessThan matches numbers and times less than the value
+func LessThan[V orderedValue](value V) Filter {
+	return createFilter("LessThan", value)
+}
+
+// LessThanOrEquals matches numbers and times less than or equal to the value
+func LessThanOrEquals[V orderedValue](value V) Filter {
+	return createFilter("LessThanOrEquals", value)
+}
+
+// Contains matches networks containing the IP or network, or strings containing the substring
+func Contains[V networkValue](value V) Filter {
+	return createFilter("Contains", value)
+}
+
+// ContainedBy matches IPs and networks within the network
+func ContainedBy[V prefixValue](network V) Filter {
+	return createFilter("ContainedBy", network)
+}
+
+// ContainedOnlyBy matches IPs and networks within the network, but not within another network inside of it
+func ContainedOnlyBy[V prefixValue](network V) Filter {
+	return createFilter("ContainedOnlyBy", network)
+}
+
+// Overlaps matches networks overlapping with the network
+func Overlaps[V prefixValue](network V) Filter {
+	return createFilter("Overlaps", network)
+}
+
 func createFilter(filterType string, value any) Filter {
 	return Filter{
 		filterType: value,
@@ -0,0 +1,66 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterConstructors(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	filters := Filters{
+		"hostname":   StartsWith("web"),
+		"memory":     GreaterThanOrEquals(4096),
+		"load":       LessThan(0.5),
+		"created":    GreaterThan(created),
+		"network":    Contains(netip.MustParseAddr("10.0.1.5")),
+		"intern_ip":  ContainedBy(netip.MustParsePrefix("10.0.0.0/16")),
+		"route":      ContainedOnlyBy("10.0.0.0/8"),
+		"supernet":   Overlaps("10.0.1.128/25"),
+		"disk_size":  LessThanOrEquals(100),
+		"game_world": Any(1.5, 2.5),
+	}
+
+	encoded, err := json.Marshal(filters)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"hostname": {"StartsWith": "web"},
+		"memory": {"GreaterThanOrEquals": 4096},
+		"load": {"LessThan": 0.5},
+		"created": {"GreaterThan": "2024-05-01T12:00:00Z"},
+		"network": {"Contains": "10.0.1.5"},
+		"intern_ip": {"ContainedBy": "10.0.0.0/16"},
+		"route": {"ContainedOnlyBy": "10.0.0.0/8"},
+		"supernet": {"Overlaps": "10.0.1.128/25"},
+		"disk_size": {"LessThanOrEquals": 100},
+		"game_world": {"Any": [1.5, 2.5]}
+	}`, string(encoded))
+
+	object := NewServerObject(map[string]any{
+		"hostname":   "web01.example.com",
+		"memory":     json.Number("8192"),
+		"load":       json.Number("0.25"),
+		"created":    "2024-06-01T08:00:00Z",
+		"network":    "10.0.1.0/24",
+		"intern_ip":  "10.0.1.5",
+		"route":      "10.0.0.0/16",
+		"supernet":   "10.0.0.0/16",
+		"disk_size":  json.Number("100"),
+		"game_world": json.Number("2.5"),
+	})
+	matched, err := filters.Match(object)
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, err = Filters{"created": LessThan(created)}.Match(object)
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	matched, err = Filters{"created": Any(created.AddDate(0, 1, 0).Add(-4 * time.Hour))}.Match(object)
+	require.NoError(t, err)
+	assert.True(t, matched)
+}
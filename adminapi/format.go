@@ -0,0 +1,133 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FormatQuery returns the filters in the query string syntax, see Filters.String()
+func FormatQuery(filters Filters) string {
+	return filters.String()
+}
+
+// String formats the filters as query string, like "game_world=any(1 2 3) hostname=regexp(foo.*)". The
+// attributes are sorted and the functions lowercased. Strings are quoted if needed, like for spaces or when
+// they would be parsed as number, and quotes in them are escaped with a backslash.
+//
+// Filters returned by ParseQuery() are parsed back into the same filters. Other filters are parsed back into
+// equivalent ones with the shapes ParseQuery() returns, which are formatted into the same string again:
+// Empty() becomes {"Empty": []}, functions with a single argument like Any("x") become {"Any": "x"},
+// IPs, networks, times and other values become strings, and nil becomes empty().
+func (f Filters) String() string {
+	parts := make([]string, 0, len(f))
+	for _, attribute := range slices.Sorted(maps.Keys(f)) {
+		parts = append(parts, attribute+"="+formatFilter(f[attribute]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// formatFilter formats a plain value or a filter function with its arguments
+func formatFilter(filter any) string {
+	function, argument, isFunction, err := splitFilter(filter)
+	if err != nil {
+		return fmt.Sprint(filter)
+	}
+	if !isFunction {
+		return formatValue(filter)
+	}
+
+	args := filterArgs(argument)
+	formatted := make([]string, len(args))
+	for idx, arg := range args {
+		formatted[idx] = formatFilter(arg)
+	}
+
+	return strings.ToLower(function) + "(" + strings.Join(formatted, " ") + ")"
+}
+
+func formatValue(val any) string {
+	switch typed := val.(type) {
+	case nil:
+		return "empty()"
+	case string:
+		return quoteString(typed)
+	case json.Number:
+		return typed.String()
+	case float64:
+		return formatFloat(typed, 64)
+	case float32:
+		return formatFloat(float64(typed), 32)
+	case time.Time:
+		return typed.Format(time.RFC3339Nano)
+	case netip.Addr, netip.Prefix, bool:
+		return fmt.Sprint(typed)
+	}
+	if number, isInt := toInt(val); isInt {
+		return strconv.Itoa(number)
+	}
+
+	return quoteString(fmt.Sprint(val))
+}
+
+// formatFloat always adds a decimal point or exponent, so the float isn't parsed back as int
+func formatFloat(number float64, bitSize int) string {
+	formatted := strconv.FormatFloat(number, 'g', -1, bitSize)
+	if strings.ContainsAny(formatted, ".eIN") {
+		return formatted
+	}
+
+	return formatted + ".0"
+}
+
+// quoteString quotes strings which ParseQuery() would split or parse as another type. Strings containing
+// double quotes are quoted with single quotes if possible, otherwise the double quotes are escaped.
+func quoteString(str string) string {
+	if !needsQuotes(str) {
+		return str
+	}
+
+	quote := `"`
+	if strings.Contains(str, `"`) && !strings.Contains(str, "'") {
+		quote = "'"
+	}
+
+	var quoted strings.Builder
+	quoted.WriteString(quote)
+	for i := 0; i < len(str); i++ {
+		// only backslashes which would be read as escape are escaped, so regexps like \d stay readable
+		if str[i] == '\\' && (i+1 == len(str) || strings.IndexByte(`\"'`, str[i+1]) >= 0) {
+			quoted.WriteByte('\\')
+		}
+		if str[i] == quote[0] {
+			quoted.WriteByte('\\')
+		}
+		quoted.WriteByte(str[i])
+	}
+	quoted.WriteString(quote)
+
+	return quoted.String()
+}
+
+func needsQuotes(str string) bool {
+	if str == "" || str == "true" || str == "false" {
+		return true
+	}
+	if strings.ContainsFunc(str, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`()"'`, r)
+	}) {
+		return true
+	}
+	if _, err := strconv.ParseFloat(str, 64); err == nil {
+		return true
+	}
+
+	return false
+}
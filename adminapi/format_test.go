@@ -0,0 +1,78 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiltersString(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters Filters
+		want    string
+	}{
+		{"example", Filters{"hostname": Regexp("foo.*"), "game_world": Any(1, 2, 3)}, "game_world=any(1 2 3) hostname=regexp(foo.*)"},
+		{"int", Filters{"memory": 1024}, "memory=1024"},
+		{"float", Filters{"ratio": 0.5, "memory": 2.0}, "memory=2.0 ratio=0.5"},
+		{"json number", Filters{"memory": json.Number("1024")}, "memory=1024"},
+		{"bool", Filters{"active": true}, "active=true"},
+		{"quoted", Filters{"a": "with space", "b": "", "c": "123", "d": "true", "e": `say "hi"`}, `a="with space" b="" c="123" d="true" e='say "hi"'`},
+		{"both quotes", Filters{"a": `it's "x"`}, `a="it's \"x\""`},
+		{"backslashes", Filters{"a": `C:\dir\`, "b": `say \"hi\"`, "c": `\d+`}, `a=C:\dir\ b='say \\"hi\\"' c=\d+`},
+		{"quoted backslashes", Filters{"a": `a b\`, "b": `"it's" \\`}, `a="a b\\" b="\"it's\" \\\\"`},
+		{"quoted regexp", Filters{"hostname": Regexp(`^(web|db)\d+`)}, `hostname=regexp("^(web|db)\d+")`},
+		{"nested", Filters{"tags": Not(Any(Empty(), StartsWith("foo bar")))}, `tags=not(any(empty() startswith("foo bar")))`},
+		{"network", Filters{"intern_ip": ContainedBy(netip.MustParsePrefix("10.0.0.0/8"))}, "intern_ip=containedby(10.0.0.0/8)"},
+		{"time", Filters{"last_seen": LessThan(time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC))}, "last_seen=lessthan(2025-01-31T12:00:00Z)"},
+		{"nil", Filters{"comment": nil}, "comment=empty()"},
+		{"decoded from JSON", Filters{"hostname": map[string]any{"Any": []any{"a", "b"}}}, "hostname=any(a b)"},
+		{"empty", Filters{}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.filters.String())
+			assert.Equal(t, test.want, FormatQuery(test.filters))
+		})
+	}
+
+	// parsing the formatted query gives back the same filters
+	queries := []string{
+		"hostname=regexp(foo.*) game_world=any(1 2 3)",
+		`description="quoted string" memory=2.0 ratio=0.5 active=false`,
+		`a="" b="123" c='say "hi"' d=all("x y" 'a(b)')`,
+		"tags=not(any(empty() startswith(foo))) memory=greaterthan(1024)",
+		"intern_ip=containedby(10.0.0.0/8) network=overlaps(10.0.0.0/16)",
+	}
+	for _, query := range queries {
+		filters, err := ParseQuery(query)
+		require.NoError(t, err)
+		parsed, err := ParseQuery(filters.String())
+		require.NoError(t, err, filters.String())
+		assert.Equal(t, filters, parsed, filters.String())
+	}
+
+	// other filters are parsed back into equivalent ones, which are formatted the same again
+	others := []Filters{
+		{"a": `it's "x"`, "b": `'\"\\`, "c": `a\`},
+		{"a": Empty(), "b": Not(Empty())},
+		{"a": Any("x"), "b": All(1)},
+		{"a": ContainedBy(netip.MustParsePrefix("10.0.0.0/8")), "b": LessThan(time.Now())},
+		{"a": nil},
+	}
+	for _, filters := range others {
+		parsed, err := ParseQuery(filters.String())
+		require.NoError(t, err, filters.String())
+		assert.Equal(t, filters.String(), parsed.String())
+	}
+	parsed, err := ParseQuery(others[0].String())
+	require.NoError(t, err)
+	assert.Equal(t, others[0], parsed, "strings are parsed back exactly")
+	parsed, err = ParseQuery(Filters{"a": Empty(), "b": Any("x")}.String())
+	require.NoError(t, err)
+	assert.Equal(t, Filters{"a": Filter{"Empty": []any{}}, "b": Filter{"Any": "x"}}, parsed)
+}
@@ -0,0 +1,218 @@
+package adminapi
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Match checks locally if the object matches all filters, like SA would evaluate the query. It can be
+// used to filter already loaded objects further. All filtered attributes have to be loaded.
+//
+// Plain values and the functions comparing values match a multi attribute if any of its values matches.
+// IPs and networks are compared by address, so ContainedBy("10.0.0.0/8") matches the IP "10.1.2.3".
+// ContainedOnlyBy is evaluated like ContainedBy, as the networks in between are only known by SA.
+// Regexp patterns are compiled with Go's regexp package, so PostgreSQL only syntax like lookaheads fails.
+func (f Filters) Match(object ServerObject) (bool, error) {
+	for attribute, filter := range f {
+		value, err := object.lookup(attribute)
+		if err != nil {
+			return false, err
+		}
+
+		matched, err := matchFilter(normalizeValue(value), filter)
+		if err != nil {
+			return false, fmt.Errorf("attribute %s: %w", attribute, err)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// matchFilter checks if an attribute value matches a plain value or a filter function like {"Regexp": "^web"}
+func matchFilter(value any, filter any) (bool, error) {
+	function, argument, isFunction, err := splitFilter(filter)
+	if err != nil {
+		return false, err
+	}
+	if !isFunction {
+		return matchValues(value, func(elem any) bool { return matchEqual(elem, filter) }), nil
+	}
+
+	switch function {
+	case "Any":
+		for _, subFilter := range filterArguments(argument) {
+			if matched, err := matchFilter(value, subFilter); err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	case "All":
+		for _, subFilter := range filterArguments(argument) {
+			if matched, err := matchFilter(value, subFilter); err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	case "Not":
+		matched, err := matchFilter(value, argument)
+		return !matched, err
+	case "Empty":
+		values, isMulti := value.([]any)
+		return value == nil || (isMulti && len(values) == 0), nil
+	default:
+		return matchFunction(function, value, argument)
+	}
+}
+
+// splitFilter returns the function name and argument of a filter function, which is a Filter or a
+// map[string]any when decoded from JSON
+func splitFilter(filter any) (string, any, bool, error) {
+	var filterMap map[string]any
+	switch typed := filter.(type) {
+	case Filter:
+		filterMap = typed
+	case map[string]any:
+		filterMap = typed
+	default:
+		return "", nil, false, nil
+	}
+
+	if len(filterMap) != 1 {
+		return "", nil, false, fmt.Errorf("invalid filter %v, expected exactly one function", filter)
+	}
+	for function, argument := range filterMap {
+		return function, argument, true, nil
+	}
+
+	return "", nil, false, nil
+}
+
+// filterArguments returns the arguments of Any() and All(), a single argument isn't wrapped in a slice
+func filterArguments(argument any) []any {
+	if values, ok := toAnySlice(argument); ok {
+		return values
+	}
+
+	return []any{argument}
+}
+
+// matchFunction evaluates the functions comparing the attribute value with their argument
+func matchFunction(function string, value any, argument any) (bool, error) {
+	var predicate func(elem any) bool
+	switch function {
+	case "Regexp":
+		pattern, ok := argument.(string)
+		if !ok {
+			return false, fmt.Errorf("argument of Regexp must be a string, got %v", argument)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		predicate = func(elem any) bool { return re.MatchString(matchString(elem)) }
+	case "StartsWith":
+		predicate = func(elem any) bool { return strings.HasPrefix(matchString(elem), matchString(argument)) }
+	case "GreaterThan":
+		predicate = func(elem any) bool { return compareMatchValues(elem, argument) > 0 }
+	case "GreaterThanOrEquals":
+		predicate = func(elem any) bool { return compareMatchValues(elem, argument) >= 0 }
+	case "LessThan":
+		predicate = func(elem any) bool { return compareMatchValues(elem, argument) < 0 }
+	case "LessThanOrEquals":
+		predicate = func(elem any) bool { return compareMatchValues(elem, argument) <= 0 }
+	case "Contains":
+		predicate = func(elem any) bool { return matchContains(elem, argument) }
+	case "ContainedBy", "ContainedOnlyBy":
+		predicate = func(elem any) bool { return matchContains(argument, elem) }
+	case "Overlaps":
+		predicate = func(elem any) bool { return matchOverlaps(elem, argument) }
+	default:
+		return false, fmt.Errorf("unknown filter function %s", function)
+	}
+
+	// null values never match a comparison
+	return matchValues(value, func(elem any) bool { return elem != nil && predicate(elem) }), nil
+}
+
+// matchValues applies the predicate to a single value, or to all values of a multi attribute
+func matchValues(value any, predicate func(elem any) bool) bool {
+	if values, isMulti := value.([]any); isMulti {
+		return slices.ContainsFunc(values, predicate)
+	}
+
+	return predicate(value)
+}
+
+// matchEqual compares like equalValues(), but also matches the string representation, as SA
+// converts the filter value to the type of the attribute, like for hostname=123
+func matchEqual(value any, filter any) bool {
+	if value == nil || filter == nil {
+		return value == nil && filter == nil
+	}
+
+	return equalValues(value, filter) || compareMatchValues(value, filter) == 0
+}
+
+// matchContains checks if the network a contains the IP or network b, or for other values if a contains b as substring
+func matchContains(a, b any) bool {
+	aPrefix, aIsNetwork := toPrefix(a)
+	bPrefix, bIsNetwork := toPrefix(b)
+	if aIsNetwork && bIsNetwork {
+		return aPrefix.Bits() <= bPrefix.Bits() && aPrefix.Masked().Contains(bPrefix.Addr())
+	}
+
+	return strings.Contains(matchString(a), matchString(b))
+}
+
+func matchOverlaps(a, b any) bool {
+	aPrefix, aIsNetwork := toPrefix(a)
+	bPrefix, bIsNetwork := toPrefix(b)
+	if aIsNetwork && bIsNetwork {
+		return aPrefix.Masked().Overlaps(bPrefix.Masked())
+	}
+
+	return matchContains(a, b) || matchContains(b, a)
+}
+
+// compareMatchValues orders numbers by value, times by instant if one of them is a time.Time,
+// and all other values by their string representation
+func compareMatchValues(a, b any) int {
+	_, aIsTime := a.(time.Time)
+	_, bIsTime := b.(time.Time)
+	if aIsTime || bIsTime {
+		aTime, aOK := toTime(a)
+		bTime, bOK := toTime(b)
+		if aOK && bOK {
+			return aTime.Compare(bTime)
+		}
+	}
+
+	// compare integers without going through float64, like equalValues()
+	if aInt, aIsInt := toInt(a); aIsInt {
+		if bInt, bIsInt := toInt(b); bIsInt {
+			return cmp.Compare(aInt, bInt)
+		}
+	}
+	aNumber, aIsNumber := toFloat(a)
+	bNumber, bIsNumber := toFloat(b)
+	if aIsNumber && bIsNumber {
+		return cmp.Compare(aNumber, bNumber)
+	}
+
+	return strings.Compare(matchString(a), matchString(b))
+}
+
+func matchString(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	return fmt.Sprint(normalizeValue(value))
+}
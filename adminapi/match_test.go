@@ -0,0 +1,80 @@
+package adminapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiltersMatch(t *testing.T) {
+	object := NewServerObject(map[string]any{
+		"object_id": json.Number("1"),
+		"hostname":  "web01.example.com",
+		"memory":    json.Number("4096"),
+		"intern_ip": "10.0.1.5",
+		"network":   "10.0.1.0/24",
+		"tags":      []any{"web", "prod"},
+		"ports":     []any{json.Number("80"), json.Number("443")},
+		"comment":   nil,
+	})
+
+	tests := []struct {
+		name    string
+		filters Filters
+		want    bool
+	}{
+		{"plain value", Filters{"hostname": "web01.example.com"}, true},
+		{"other value", Filters{"hostname": "web02.example.com"}, false},
+		{"number", Filters{"memory": 4096}, true},
+		{"number as string", Filters{"memory": "4096"}, true},
+		{"multi value", Filters{"tags": "web"}, true},
+		{"multi number", Filters{"ports": 443}, true},
+		{"regexp", Filters{"hostname": Regexp(`^web\d+\.`)}, true},
+		{"regexp on multi", Filters{"tags": Regexp("^pr")}, true},
+		{"startswith", Filters{"hostname": Filter{"StartsWith": "db"}}, false},
+		{"any", Filters{"hostname": Any("foo", "web01.example.com")}, true},
+		{"any ints", Filters{"memory": Any(1024, 2048)}, false},
+		{"any filters", Filters{"hostname": Any(Regexp("^db"), Regexp("example"))}, true},
+		{"all", Filters{"tags": All("web", "prod")}, true},
+		{"all missing", Filters{"tags": All("web", "staging")}, false},
+		{"not", Filters{"tags": Not("staging")}, true},
+		{"not on multi", Filters{"tags": Not("web")}, false},
+		{"empty", Filters{"comment": Empty()}, true},
+		{"not empty", Filters{"tags": Not(Empty())}, true},
+		{"greater than", Filters{"memory": Filter{"GreaterThan": 2048}}, true},
+		{"less than or equals", Filters{"memory": Filter{"LessThanOrEquals": 2048.5}}, false},
+		{"null is not compared", Filters{"comment": Filter{"LessThan": "z"}}, false},
+		{"contained by", Filters{"intern_ip": Filter{"ContainedBy": "10.0.0.0/16"}}, true},
+		{"not contained by", Filters{"intern_ip": Filter{"ContainedOnlyBy": "10.0.2.0/24"}}, false},
+		{"network contains ip", Filters{"network": Filter{"Contains": "10.0.1.200"}}, true},
+		{"network contains bigger network", Filters{"network": Filter{"Contains": "10.0.0.0/16"}}, false},
+		{"string contains", Filters{"hostname": Filter{"Contains": "example"}}, true},
+		{"overlaps", Filters{"network": Filter{"Overlaps": "10.0.0.0/8"}}, true},
+		{"multiple filters", Filters{"hostname": Regexp("^web"), "memory": 1024}, false},
+		{"decoded from JSON", Filters{"hostname": map[string]any{"Any": []any{map[string]any{"Regexp": "^web"}}}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matched, err := test.filters.Match(object)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, matched)
+		})
+	}
+
+	parsed, err := ParseQuery("hostname=regexp(^web) memory=GreaterThan(1024) tags=not(empty())")
+	require.NoError(t, err)
+	matched, err := parsed.Match(object)
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	_, err = Filters{"nope": "foo"}.Match(object)
+	require.EqualError(t, err, "attribute nope is not loaded, add it via Query.SetAttributes()")
+
+	_, err = Filters{"hostname": Filter{"Nope": 1}}.Match(object)
+	require.EqualError(t, err, "attribute hostname: unknown filter function Nope")
+
+	_, err = Filters{"hostname": Regexp("(")}.Match(object)
+	require.ErrorContains(t, err, "attribute hostname: error parsing regexp")
+}
@@ -0,0 +1,155 @@
+package adminapi
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+)
+
+// MultiAttr provides set-like access to a multi attribute of a ServerObject.
+// Changes done via Add() and Remove() are recorded in the object and committed as
+// "add"/"remove" deltas, so they don't overwrite concurrent changes of other values.
+type MultiAttr struct {
+	object    *ServerObject
+	attribute string
+}
+
+// GetMulti returns the given multi attribute of the object
+func (s *ServerObject) GetMulti(attribute string) (MultiAttr, error) {
+	val, err := s.lookup(attribute)
+	if err != nil {
+		return MultiAttr{}, err
+	}
+	if _, isMulti := val.([]any); !isMulti {
+		return MultiAttr{}, fmt.Errorf("attribute %s is not a multi attribute", attribute)
+	}
+
+	return MultiAttr{object: s, attribute: attribute}, nil
+}
+
+// Values returns a copy of all values of the attribute
+func (m MultiAttr) Values() []any {
+	values := make([]any, 0, m.Len())
+	for val := range m.All() {
+		values = append(values, val)
+	}
+
+	return values
+}
+
+// All iterates over all values of the attribute
+func (m MultiAttr) All() iter.Seq[any] {
+	return func(yield func(any) bool) {
+		for _, val := range m.values() {
+			if !yield(normalizeValue(val)) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of values of the attribute
+func (m MultiAttr) Len() int {
+	return len(m.values())
+}
+
+// Contains checks if the given value is part of the attribute
+func (m MultiAttr) Contains(value any) bool {
+	return containsValue(m.values(), value)
+}
+
+// Add adds the given values to the attribute, values which are already present are ignored
+func (m MultiAttr) Add(values ...any) {
+	newValues := slices.Clone(m.values())
+	for _, val := range values {
+		if !containsValue(newValues, val) {
+			newValues = append(newValues, val)
+		}
+	}
+
+	m.set(newValues)
+}
+
+// Remove removes the given values from the attribute, values which are not present are ignored
+func (m MultiAttr) Remove(values ...any) {
+	newValues := slices.DeleteFunc(slices.Clone(m.values()), func(val any) bool {
+		return containsValue(values, val)
+	})
+
+	m.set(newValues)
+}
+
+func (m MultiAttr) values() []any {
+	values, _ := m.object.attributes[m.attribute].([]any)
+
+	return values
+}
+
+func (m MultiAttr) set(values []any) {
+	// can't fail: GetMulti() already made sure that the attribute is loaded
+	_ = m.object.Set(m.attribute, values)
+}
+
+// multiChange builds the "multi" action of the commit payload: only the delta between old and new values is sent
+func multiChange(oldValues, newValues []any) map[string]any {
+	return map[string]any{
+		"action": "multi",
+		"add":    diffValues(newValues, oldValues),
+		"remove": diffValues(oldValues, newValues),
+	}
+}
+
+// diffValues returns all values of a which are not part of b
+func diffValues(a, b []any) []any {
+	diff := make([]any, 0)
+	for _, val := range a {
+		if !containsValue(b, val) {
+			diff = append(diff, val)
+		}
+	}
+
+	return diff
+}
+
+func containsValue(values []any, value any) bool {
+	return slices.ContainsFunc(values, func(val any) bool {
+		return equalValues(val, value)
+	})
+}
+
+// equalValues compares two attribute values, numbers are compared by value regardless of their type
+func equalValues(a, b any) bool {
+	// compare integers without going through float64, to not lose precision of large numbers
+	if aInt, ok := toInt(a); ok {
+		bInt, ok := toInt(b)
+		if ok {
+			return aInt == bInt
+		}
+	}
+	if aNum, ok := toFloat(a); ok {
+		bNum, ok := toFloat(b)
+		return ok && aNum == bNum
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// toAnySlice converts any kind of slice (like []string) into []any
+func toAnySlice(val any) ([]any, bool) {
+	if values, ok := val.([]any); ok {
+		return slices.Clone(values), true
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	values := make([]any, rv.Len())
+	for idx := range values {
+		values[idx] = rv.Index(idx).Interface()
+	}
+
+	return values, true
+}
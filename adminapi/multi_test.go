@@ -0,0 +1,72 @@
+package adminapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiAttr(t *testing.T) {
+	object := newServerObject(map[string]any{
+		"object_id": 483903.0,
+		"hostname":  "foo.bar.local",
+		"tags":      []any{"web", "prod"},
+		"ports":     []any{80.0, 443.0},
+	})
+
+	_, err := object.GetMulti("hostname")
+	require.EqualError(t, err, "attribute hostname is not a multi attribute")
+	_, err = object.GetMulti("nope")
+	require.EqualError(t, err, "attribute nope is not loaded, add it via Query.SetAttributes()")
+
+	ports, err := object.GetMulti("ports")
+	require.NoError(t, err)
+	assert.Equal(t, []any{80, 443}, ports.Values())
+	assert.True(t, ports.Contains(443))
+	assert.False(t, ports.Contains(8080))
+	assert.False(t, object.HasChanges())
+
+	tags, err := object.GetMulti("tags")
+	require.NoError(t, err)
+	tags.Add("web", "backup")
+	tags.Remove("prod", "unknown")
+	assert.Equal(t, 2, tags.Len())
+	assert.Equal(t, []any{"web", "backup"}, tags.Values())
+	assert.Equal(t, []any{"web", "backup"}, object.Get("tags"))
+
+	var iterated []any
+	for tag := range tags.All() {
+		iterated = append(iterated, tag)
+	}
+	assert.Equal(t, []any{"web", "backup"}, iterated)
+
+	// only the delta is committed
+	assert.Equal(t, map[string]any{
+		"object_id": 483903,
+		"tags": map[string]any{
+			"action": "multi",
+			"add":    []any{"backup"},
+			"remove": []any{"prod"},
+		},
+	}, object.changeSet())
+}
+
+func TestSetMultiAttr(t *testing.T) {
+	object := newServerObject(map[string]any{
+		"object_id": 483903.0,
+		"ports":     []any{80.0, 443.0},
+	})
+
+	require.EqualError(t, object.Set("ports", 80), "attribute ports is a multi attribute, got int")
+	require.NoError(t, object.Set("ports", []int{443, 8080}))
+
+	assert.Equal(t, map[string]any{
+		"object_id": 483903,
+		"ports": map[string]any{
+			"action": "multi",
+			"add":    []any{8080},
+			"remove": []any{80.0},
+		},
+	}, object.changeSet())
+}
@@ -15,6 +15,8 @@ import (
 //	"hostname=11111"                               => map: {"hostname": 11111}
 //	"hostname=regexp(foo.*) game_world=any(1 2 3)" => map: {"hostname": {"Regexp": "foo.*"}, "game_world": {"Any": [1, 2, 3]}}
 //	"hostname=Not(Empty())"                        => map: {"hostname": {"Not": {"Empty": nil}}}
+//
+// Quoted strings can contain \", \' and \\ as escapes, other backslashes are kept like in "regexp(\d+)".
 func ParseQuery(query string) (Filters, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
@@ -44,6 +46,9 @@ func ParseQuery(query string) (Filters, error) {
 		}
 		filters[key] = val
 	}
+	if err := filters.Validate(); err != nil {
+		return nil, err
+	}
 	return filters, nil
 }
 
@@ -53,13 +58,18 @@ func splitPairs(s string) ([]string, error) {
 	start := 0
 	depth := 0
 	inQuotes := rune(0)
+	escaped := false
 	for i, r := range s {
 		switch {
-		case (r == '\'' || r == '"') && (i == 0 || s[i-1] != '\\'):
-			switch inQuotes {
-			case 0:
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes != 0:
+			escaped = true
+		case r == '\'' || r == '"':
+			switch {
+			case inQuotes == 0 && (i == 0 || s[i-1] != '\\'):
 				inQuotes = r
-			case r:
+			case inQuotes == r:
 				inQuotes = 0
 			}
 		case inQuotes != 0:
@@ -93,7 +103,7 @@ func parseValue(s string) (any, error) {
 	s = strings.TrimSpace(s)
 	// Recognize quoted strings
 	if l := len(s); l >= 2 && ((s[0] == '"' && s[l-1] == '"') || (s[0] == '\'' && s[l-1] == '\'')) {
-		return s[1 : l-1], nil
+		return unescape(s[1 : l-1]), nil
 	}
 
 	// Try int
@@ -155,3 +165,20 @@ func parseValue(s string) (any, error) {
 	// If not a filter, treat as simple string
 	return s, nil
 }
+
+// unescape resolves \\, \" and \' in quoted strings. Other backslashes are kept, like the ones of regexps.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`\"'`, s[i+1]) >= 0 {
+			i++
+		}
+		unescaped.WriteByte(s[i])
+	}
+
+	return unescaped.String()
+}
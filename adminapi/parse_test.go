@@ -123,8 +123,38 @@ func TestParseQuery(t *testing.T) {
 		},
 		{
 			name:  "overlaps with capital letters",
-			query: "field=OverLapS(A B C)",
-			want:  Filters{"field": Filter{"Overlaps": []any{"A", "B", "C"}}},
+			query: "field=OverLapS(10.0.0.0/8)",
+			want:  Filters{"field": Filter{"Overlaps": "10.0.0.0/8"}},
+		},
+		{
+			name:        "overlaps with multiple networks",
+			query:       "field=Overlaps(10.0.0.0/8 192.168.0.0/16)",
+			expectError: true,
+		},
+		{
+			name:  "escaped quotes",
+			query: `a="it's \"x\"" b='\'' c="\\d+\\" d=regexp("\d+")`,
+			want:  Filters{"a": `it's "x"`, "b": "'", "c": `\d+\`, "d": Filter{"Regexp": `\d+`}},
+		},
+		{
+			name:  "regexp with PostgreSQL syntax",
+			query: `hostname=regexp("^(?!web).*")`,
+			want:  Filters{"hostname": Filter{"Regexp": "^(?!web).*"}},
+		},
+		{
+			name:        "regexp with multiple arguments",
+			query:       "hostname=Regexp(a b c)",
+			expectError: true,
+		},
+		{
+			name:        "empty with an argument",
+			query:       "hostname=Empty(5)",
+			expectError: true,
+		},
+		{
+			name:        "greater than with strings",
+			query:       `memory=GreaterThan("x" "y")`,
+			expectError: true,
 		},
 		{
 			name:        "invalid function name",
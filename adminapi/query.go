@@ -1,7 +1,7 @@
 package adminapi
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/url"
 	"slices"
@@ -9,6 +9,7 @@ import (
 
 // Query is a struct to build a query to the SA API
 type Query struct {
+	client               *Client
 	filters              Filters
 	restrictedAttributes []string
 	orderBy              string
@@ -46,9 +47,19 @@ func (q *Query) AddFilter(attribute string, filter any) {
 	q.filters[attribute] = filter
 }
 
+// String returns the effective filters of the query in the query string syntax
+func (q *Query) String() string {
+	return q.filters.String()
+}
+
 // Count matching SA objects
 func (q *Query) Count() (int, error) {
-	err := q.load()
+	return q.CountContext(context.Background())
+}
+
+// CountContext counts matching SA objects, the context is used for the request to SA
+func (q *Query) CountContext(ctx context.Context) (int, error) {
+	err := q.load(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -58,7 +69,12 @@ func (q *Query) Count() (int, error) {
 
 // All returns all matching SA objects
 func (q *Query) All() (ServerObjects, error) {
-	err := q.load()
+	return q.AllContext(context.Background())
+}
+
+// AllContext returns all matching SA objects, the context is used for the request to SA
+func (q *Query) AllContext(ctx context.Context) (ServerObjects, error) {
+	err := q.load(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +84,12 @@ func (q *Query) All() (ServerObjects, error) {
 
 // One returns exactly one matching SA object. If there is none or more than one, an error is returned.
 func (q *Query) One() (ServerObject, error) {
-	err := q.load()
+	return q.OneContext(context.Background())
+}
+
+// OneContext returns exactly one matching SA object, the context is used for the request to SA
+func (q *Query) OneContext(ctx context.Context) (ServerObject, error) {
+	err := q.load(ctx)
 	if err != nil {
 		return ServerObject{}, err
 	}
@@ -80,7 +101,31 @@ func (q *Query) One() (ServerObject, error) {
 	return q.serverObjects[0], nil
 }
 
-func (q *Query) load() error {
+// Delete marks all matching SA objects to be deleted with the next Commit(). As a safety net, nothing
+// is marked when the query matches more than the expected number of objects.
+func (q *Query) Delete(expected int) error {
+	return q.DeleteContext(context.Background(), expected)
+}
+
+// DeleteContext marks all matching SA objects to be deleted, the context is used for loading the objects
+func (q *Query) DeleteContext(ctx context.Context, expected int) error {
+	err := q.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(q.serverObjects) > expected {
+		return fmt.Errorf("refusing to delete %d server objects, expected at most %d", len(q.serverObjects), expected)
+	}
+
+	for idx := range q.serverObjects {
+		q.serverObjects[idx].Delete()
+	}
+
+	return nil
+}
+
+func (q *Query) load(ctx context.Context) error {
 	if q.loaded {
 		return nil
 	}
@@ -96,21 +141,25 @@ func (q *Query) load() error {
 		OrderBy:    q.orderBy,
 	}
 
-	resp, err := sendRequest(apiEndpointQuery, request)
+	client, err := resolveClient(q.client)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.sendRequest(ctx, apiEndpointQuery, request)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	respServer := queryResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&respServer)
+	err = decodeResponse(resp.Body, &respServer)
 
 	// map attribute map into ServerObject objects
 	q.serverObjects = make(ServerObjects, len(respServer.Result))
 	for idx, object := range respServer.Result {
-		q.serverObjects[idx] = ServerObject{
-			attributes: object,
-		}
+		q.serverObjects[idx] = newServerObject(object)
+		q.serverObjects[idx].client = client
 	}
 	q.loaded = true
 
@@ -119,20 +168,42 @@ func (q *Query) load() error {
 
 // NewObject creates a new server object (fetches default attributes from SA)
 func NewObject(serverType string) (ServerObject, error) {
-	server := ServerObject{}
+	return NewObjectContext(context.Background(), serverType)
+}
+
+// NewObjectContext creates a new server object, the context is used for the request to SA
+func NewObjectContext(ctx context.Context, serverType string) (ServerObject, error) {
+	client, err := resolveClient(nil)
+	if err != nil {
+		return ServerObject{}, err
+	}
+
+	return client.NewObjectContext(ctx, serverType)
+}
+
+// NewObject creates a new server object via this client (fetches default attributes from SA)
+func (c *Client) NewObject(serverType string) (ServerObject, error) {
+	return c.NewObjectContext(context.Background(), serverType)
+}
+
+// NewObjectContext creates a new server object via this client, the context is used for the request to SA
+func (c *Client) NewObjectContext(ctx context.Context, serverType string) (ServerObject, error) {
+	server := newServerObject(nil)
+	server.client = c
+	server.state.isNew = true
 
 	// Use url.Values for safe query string encoding
 	params := url.Values{}
 	params.Add("servertype", serverType)
 	fullURL := apiEndpointNewObject + "?" + params.Encode()
 
-	resp, err := sendRequest(fullURL, nil)
+	resp, err := c.sendRequest(ctx, fullURL, nil)
 	if err != nil {
 		return server, err
 	}
 	defer resp.Body.Close()
 
-	err = json.NewDecoder(resp.Body).Decode(&server.attributes)
+	err = decodeResponse(resp.Body, &server.attributes)
 
 	return server, err
 }
@@ -0,0 +1,98 @@
+package adminapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// RetryPolicy defines how requests failing with transient errors, like during a SA deployment, are retried.
+// Every attempt is signed again with a fresh timestamp.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one, 1 or less disables retries
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry, it's doubled for every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the wait time between two attempts, 0 means no limit
+	MaxBackoff time.Duration
+	// RetryableStatusCodes are the HTTP status codes considered as transient, network errors are always retried
+	RetryableStatusCodes []int
+	// RetryCommits also retries commits. If the response of a successful commit gets lost, the retry
+	// sends the same commit again: changed objects most likely fail with a ConflictError, created
+	// objects are created a second time and deleted objects fail as they don't exist anymore.
+	// Only enable it for commits which just change existing objects.
+	RetryCommits bool
+}
+
+// DefaultRetryPolicy retries queries up to 3 times on network errors and on 502, 503 and 504, but no commits
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// NoRetries disables retries completely
+func NoRetries() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// shouldRetry decides if the request is sent again after the given failed attempt
+func (p RetryPolicy) shouldRetry(ctx context.Context, err error, attempt int, idempotent bool) bool {
+	if attempt >= p.MaxAttempts || (!idempotent && !p.RetryCommits) || ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *httpError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(p.RetryableStatusCodes, statusErr.statusCode)
+	}
+
+	// network errors like "connection refused" while SA is restarting
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// wait sleeps before the next attempt, unless the context is done before
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns the exponential wait time after the given attempt. A random jitter of up to
+// the half of it is applied, so many clients don't hit SA at the same time again.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	shift := min(max(attempt-1, 0), 62)
+	backoff := p.InitialBackoff << shift
+	if backoff>>shift != p.InitialBackoff {
+		// overflow of the shift
+		backoff = math.MaxInt64
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	return backoff/2 + rand.N(backoff/2+1) //nolint:gosec // no crypto needed for jitter
+}
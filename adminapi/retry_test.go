@@ -0,0 +1,129 @@
+package adminapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	var attempts int
+	failures := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		// every attempt is signed on its own
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		req, _ := io.ReadAll(r.Body)
+		assert.Equal(t, calcSecurityToken([]byte("1234567890"), timestamp, req), r.Header.Get("X-SecurityToken"))
+
+		if attempts <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"status": "success", "result": [{"object_id": 1, "hostname": "foo.bar.local"}]}`))
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	client, err := NewClient(WithBaseURL(server.URL), WithToken("1234567890"), WithRetryPolicy(policy))
+	require.NoError(t, err)
+
+	query := client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	object, err := query.One()
+	require.NoError(t, err)
+	assert.Equal(t, "foo.bar.local", object.Get("hostname"))
+	assert.Equal(t, 3, attempts)
+
+	// give up after MaxAttempts
+	attempts = 0
+	failures = 10
+	query = client.NewQuery(Filters{"hostname": "foo.bar.local"})
+	_, err = query.All()
+	require.EqualError(t, err, "HTTP error 503 Service Unavailable")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryCommit(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	client, err := NewClient(WithBaseURL(server.URL), WithToken("1234567890"), WithRetryPolicy(policy))
+	require.NoError(t, err)
+
+	object := newServerObject(map[string]any{"object_id": 1.0, "state": "online"})
+	object.client = client
+	require.NoError(t, object.Set("state", "maintenance"))
+
+	// commits are not retried by default
+	require.EqualError(t, object.Commit(), "HTTP error 502 Bad Gateway")
+	assert.Equal(t, 1, attempts)
+
+	client.retryPolicy.RetryCommits = true
+	attempts = 0
+	require.Error(t, object.Commit())
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	server := httptest.NewServer(nil)
+	server.Close()
+
+	var attempts int
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	client, err := NewClient(
+		WithBaseURL(server.URL),
+		WithRetryPolicy(policy),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			attempts++
+			return http.DefaultTransport.RoundTrip(r)
+		})}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.NewObject("vm")
+	require.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for range 100 {
+		assert.InDelta(t, 75*time.Millisecond, policy.backoff(1), float64(25*time.Millisecond))
+		assert.InDelta(t, 150*time.Millisecond, policy.backoff(2), float64(50*time.Millisecond))
+		assert.InDelta(t, 225*time.Millisecond, policy.backoff(3), float64(75*time.Millisecond))
+		assert.InDelta(t, 225*time.Millisecond, policy.backoff(100), float64(75*time.Millisecond))
+	}
+
+	// without MaxBackoff the wait time is not limited
+	policy = RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second}
+	for range 100 {
+		assert.InDelta(t, 750*time.Millisecond, policy.backoff(1), float64(250*time.Millisecond))
+		assert.InDelta(t, 6*time.Second, policy.backoff(4), float64(2*time.Second))
+		assert.Greater(t, policy.backoff(100), time.Duration(0), "no overflow")
+		assert.Greater(t, policy.backoff(40), policy.backoff(30))
+	}
+	assert.Zero(t, RetryPolicy{MaxBackoff: time.Second}.backoff(1))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
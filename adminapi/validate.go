@@ -0,0 +1,140 @@
+package adminapi
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FilterError reports an invalid filter of an attribute
+type FilterError struct {
+	Attribute string
+	// Filter is the offending filter, which might be nested in the filter of the attribute
+	Filter any
+	Err    error
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter %s for attribute %s: %s", formatFilter(e.Filter), e.Attribute, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the filters before they are sent to SA: the number and types of the arguments of
+// all functions and that only Any, All and Not contain other filters. All invalid filters are
+// reported as *FilterError.
+func (f Filters) Validate() error {
+	var errs []error
+	for _, attribute := range slices.Sorted(maps.Keys(f)) {
+		if offending, err := validateFilter(f[attribute]); err != nil {
+			errs = append(errs, &FilterError{Attribute: attribute, Filter: offending, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateFilter checks a plain value or filter function and returns the offending (nested) filter on errors
+func validateFilter(filter any) (any, error) {
+	function, argument, isFunction, err := splitFilter(filter)
+	if err != nil {
+		return filter, err
+	}
+	if !isFunction {
+		return filter, validatePlainValue(filter)
+	}
+	if canonical, known := allFilters[strings.ToLower(function)]; !known || canonical != function {
+		return filter, fmt.Errorf("unknown filter function %s", function)
+	}
+
+	args := filterArgs(argument)
+	switch function {
+	case "Any", "All":
+		if len(args) == 0 {
+			return filter, fmt.Errorf("%s needs at least one argument", function)
+		}
+		for _, arg := range args {
+			if offending, err := validateFilter(arg); err != nil {
+				return offending, err
+			}
+		}
+		return nil, nil
+	case "Not":
+		if len(args) != 1 {
+			return filter, fmt.Errorf("Not takes exactly one argument, got %d", len(args))
+		}
+		return validateFilter(args[0])
+	case "Empty":
+		if len(args) != 0 {
+			return filter, fmt.Errorf("Empty takes no arguments, got %d", len(args))
+		}
+		return nil, nil
+	}
+
+	if len(args) != 1 {
+		return filter, fmt.Errorf("%s takes exactly one argument, got %d", function, len(args))
+	}
+	if _, _, isNested, _ := splitFilter(args[0]); isNested {
+		return filter, fmt.Errorf("%s can't contain other filters", function)
+	}
+
+	return filter, validateArgument(function, args[0])
+}
+
+// validateArgument checks the type of the single argument of the functions comparing values
+func validateArgument(function string, arg any) error {
+	switch function {
+	case "Regexp":
+		// the pattern itself isn't checked, as SA evaluates it with PostgreSQL, which supports more than Go
+		if _, ok := arg.(string); !ok {
+			return fmt.Errorf("Regexp needs a string, got %v (%T)", arg, arg)
+		}
+	case "StartsWith":
+		if _, ok := arg.(string); !ok {
+			return fmt.Errorf("StartsWith needs a string, got %v (%T)", arg, arg)
+		}
+	case "GreaterThan", "GreaterThanOrEquals", "LessThan", "LessThanOrEquals":
+		_, isNumber := toFloat(arg)
+		_, isTime := toTime(arg)
+		if !isNumber && !isTime {
+			return fmt.Errorf("%s needs a number or time, got %v (%T)", function, arg, arg)
+		}
+	case "ContainedBy", "ContainedOnlyBy", "Overlaps":
+		if _, ok := toPrefix(arg); !ok {
+			return fmt.Errorf("%s needs an IP network, got %v (%T)", function, arg, arg)
+		}
+	case "Contains":
+		return validatePlainValue(arg)
+	}
+
+	return nil
+}
+
+// validatePlainValue only allows single values, lists are only allowed as arguments of Any and All
+func validatePlainValue(val any) error {
+	switch val.(type) {
+	case nil, string, bool, netip.Addr, netip.Prefix, time.Time:
+		return nil
+	}
+	if _, isNumber := toFloat(val); isNumber {
+		return nil
+	}
+
+	return fmt.Errorf("unsupported value %v (%T)", val, val)
+}
+
+// filterArgs returns the arguments of a filter function, which are a slice for multiple
+// arguments, a single value for one argument or nil for none
+func filterArgs(argument any) []any {
+	if argument == nil {
+		return nil
+	}
+
+	return filterArguments(argument)
+}
@@ -0,0 +1,118 @@
+package adminapi
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiltersValidate(t *testing.T) {
+	valid := []Filters{
+		{"hostname": "web01", "memory": 1024, "ratio": 0.5, "active": true, "comment": nil},
+		{"hostname": Regexp(`^web\d+`), "memory": GreaterThan(1024)},
+		// PostgreSQL regexp syntax which Go doesn't support
+		{"hostname": Regexp("^(?!web).*")},
+		{"hostname": StartsWith("web"), "tags": Not(Empty())},
+		{"last_seen": LessThan(time.Now()), "created": GreaterThanOrEquals("2025-01-01")},
+		{"intern_ip": ContainedBy(netip.MustParsePrefix("10.0.0.0/8")), "network": Contains("10.0.0.1")},
+		{"network": Overlaps("10.0.0.0/8"), "intern_ip": ContainedOnlyBy("10.0.0.0/16")},
+		{"game_world": Any(1, 2, 3), "tags": All(Not(Empty()), Regexp("^prod"))},
+		{"hostname": map[string]any{"Any": []any{map[string]any{"Regexp": "^web"}}}},
+	}
+	for _, filters := range valid {
+		require.NoError(t, filters.Validate(), filters)
+	}
+
+	tests := []struct {
+		name    string
+		filters Filters
+		wantErr string
+	}{
+		{
+			"regexp arity",
+			Filters{"hostname": Filter{"Regexp": []any{"a", "b", "c"}}},
+			"invalid filter regexp(a b c) for attribute hostname: Regexp takes exactly one argument, got 3",
+		},
+		{
+			"regexp type",
+			Filters{"hostname": Filter{"Regexp": 5}},
+			"invalid filter regexp(5) for attribute hostname: Regexp needs a string, got 5 (int)",
+		},
+		{
+			"empty with argument",
+			Filters{"hostname": Filter{"Empty": 5}},
+			"invalid filter empty(5) for attribute hostname: Empty takes no arguments, got 1",
+		},
+		{
+			"comparison with strings",
+			Filters{"memory": Filter{"GreaterThan": []any{"x", "y"}}},
+			"invalid filter greaterthan(x y) for attribute memory: GreaterThan takes exactly one argument, got 2",
+		},
+		{
+			"comparison with string",
+			Filters{"memory": Filter{"LessThan": "x"}},
+			"invalid filter lessthan(x) for attribute memory: LessThan needs a number or time, got x (string)",
+		},
+		{
+			"network",
+			Filters{"intern_ip": Filter{"ContainedBy": "web01"}},
+			"invalid filter containedby(web01) for attribute intern_ip: ContainedBy needs an IP network, got web01 (string)",
+		},
+		{
+			"nested in leaf function",
+			Filters{"hostname": Filter{"StartsWith": Regexp("^web")}},
+			"invalid filter startswith(regexp(^web)) for attribute hostname: StartsWith can't contain other filters",
+		},
+		{
+			"offending nested filter",
+			Filters{"hostname": Any(Regexp("^web"), Not(Filter{"Regexp": 1}))},
+			"invalid filter regexp(1) for attribute hostname: Regexp needs a string, got 1 (int)",
+		},
+		{
+			"empty any",
+			Filters{"hostname": Filter{"Any": []any{}}},
+			"invalid filter any() for attribute hostname: Any needs at least one argument",
+		},
+		{
+			"not arity",
+			Filters{"hostname": Filter{"Not": []any{1, 2}}},
+			"invalid filter not(1 2) for attribute hostname: Not takes exactly one argument, got 2",
+		},
+		{
+			"unknown function",
+			Filters{"hostname": Filter{"regexp": "^web"}},
+			"invalid filter regexp(^web) for attribute hostname: unknown filter function regexp",
+		},
+		{
+			"plain list",
+			Filters{"tags": []any{"web", "prod"}},
+			"invalid filter \"[web prod]\" for attribute tags: unsupported value [web prod] ([]interface {})",
+		},
+		{
+			"multiple functions",
+			Filters{"hostname": Filter{"Regexp": "^web", "StartsWith": "web"}},
+			"invalid filter map[Regexp:^web StartsWith:web] for attribute hostname: invalid filter map[Regexp:^web StartsWith:web], expected exactly one function",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.filters.Validate()
+			require.EqualError(t, err, test.wantErr)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			for attribute := range test.filters {
+				assert.Equal(t, attribute, filterErr.Attribute)
+			}
+		})
+	}
+
+	// all invalid attributes are reported in order
+	err := Filters{"memory": Filter{"Empty": 1}, "hostname": Filter{"Regexp": 1}}.Validate()
+	require.EqualError(t, err, "invalid filter regexp(1) for attribute hostname: Regexp needs a string, got 1 (int)\n"+
+		"invalid filter empty(1) for attribute memory: Empty takes no arguments, got 1")
+}
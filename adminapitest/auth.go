@@ -0,0 +1,111 @@
+package adminapitest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // SHA1 is required by the protocol
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// gracePeriod is how far the timestamp of a request may differ from the clock of the server
+const gracePeriod = 16 * time.Second
+
+// authenticate verifies the X-SecurityToken or X-Signatures of a request like SA does
+func (s *Server) authenticate(header http.Header, body []byte) error {
+	timestamp, err := strconv.ParseInt(header.Get("X-Timestamp"), 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid X-Timestamp header")
+	}
+	if time.Since(time.Unix(timestamp, 0)).Abs() > gracePeriod {
+		return errors.New("request expired")
+	}
+
+	message := append([]byte(strconv.FormatInt(timestamp, 10)+":"), body...)
+	switch {
+	case header.Get("X-PublicKeys") != "":
+		return s.verifySignatures(header.Get("X-PublicKeys"), header.Get("X-Signatures"), message)
+	case header.Get("X-Application") != "":
+		return s.verifyToken(header.Get("X-Application"), header.Get("X-SecurityToken"), message)
+	default:
+		return errors.New("missing authentication headers")
+	}
+}
+
+// verifyToken checks the HMAC of the token which is identified by its SHA1 hash in X-Application
+func (s *Server) verifyToken(appID, securityToken string, message []byte) error {
+	for _, token := range s.tokens {
+		hash := sha1.Sum([]byte(token)) //nolint:gosec // SHA1 is required by the protocol
+		if hex.EncodeToString(hash[:]) != appID {
+			continue
+		}
+
+		mac := hmac.New(sha1.New, []byte(token))
+		mac.Write(message)
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(securityToken)) {
+			return errors.New("invalid security token")
+		}
+		return nil
+	}
+
+	return errors.New("unknown application")
+}
+
+// verifySignatures checks all comma separated signatures, at least one of the keys has to be authorized
+func (s *Server) verifySignatures(publicKeysHeader, signaturesHeader string, message []byte) error {
+	publicKeys := strings.Split(publicKeysHeader, ",")
+	signatures := strings.Split(signaturesHeader, ",")
+	if len(publicKeys) != len(signatures) {
+		return errors.New("number of public keys and signatures differ")
+	}
+
+	authorized := false
+	for idx := range publicKeys {
+		publicKey, signature, err := parseSignature(publicKeys[idx], signatures[idx])
+		if err != nil {
+			return err
+		}
+		if err = publicKey.Verify(message, signature); err != nil {
+			return errors.New("invalid signature")
+		}
+
+		authorized = authorized || slices.ContainsFunc(s.publicKeys, func(key ssh.PublicKey) bool {
+			return bytes.Equal(key.Marshal(), publicKey.Marshal())
+		})
+	}
+	if !authorized {
+		return errors.New("none of the public keys is authorized")
+	}
+
+	return nil
+}
+
+func parseSignature(publicKey, signature string) (ssh.PublicKey, *ssh.Signature, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, nil, errors.New("invalid public key encoding")
+	}
+	parsedKey, err := ssh.ParsePublicKey(keyBytes)
+	if err != nil {
+		return nil, nil, errors.New("invalid public key")
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, nil, errors.New("invalid signature encoding")
+	}
+	parsedSignature := &ssh.Signature{}
+	if err = ssh.Unmarshal(signatureBytes, parsedSignature); err != nil {
+		return nil, nil, errors.New("invalid signature")
+	}
+
+	return parsedKey, parsedSignature, nil
+}
@@ -0,0 +1,488 @@
+// Package adminapitest provides an in-memory fake of the SA API for testing code using the adminapi package.
+package adminapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/innogames/serveradmin-go-client/adminapi"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultToken is accepted when neither WithToken() nor WithPublicKeys() is given
+const defaultToken = "adminapitest"
+
+// Server is a fake SA, serving the query, new_object and commit endpoints from in-memory objects.
+// Requests have to be authenticated like for the real SA.
+type Server struct {
+	// URL is the base URL of the server, like "http://127.0.0.1:1234"
+	URL string
+
+	tb          testing.TB
+	httpServer  *httptest.Server
+	tokens      []string
+	publicKeys  []ssh.PublicKey
+	servertypes map[string]map[string]any
+
+	mu      sync.Mutex
+	objects map[int]map[string]any
+	nextID  int
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// NewServer starts a fake SA which is closed when the test finishes
+func NewServer(tb testing.TB, options ...Option) *Server {
+	tb.Helper()
+
+	server := &Server{
+		tb:          tb,
+		servertypes: map[string]map[string]any{},
+		objects:     map[int]map[string]any{},
+		nextID:      1,
+	}
+	for _, option := range options {
+		option(server)
+	}
+	if len(server.tokens) == 0 && len(server.publicKeys) == 0 {
+		server.tokens = []string{defaultToken}
+	}
+
+	server.httpServer = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	server.URL = server.httpServer.URL
+	tb.Cleanup(server.httpServer.Close)
+
+	return server
+}
+
+// WithObjects seeds the server with objects. Objects without object_id get the next free one.
+// Multi attributes are given as slices, like []string{"web"}.
+func WithObjects(objects ...map[string]any) Option {
+	return func(s *Server) {
+		s.tb.Helper()
+		for _, object := range objects {
+			s.AddObject(object)
+		}
+	}
+}
+
+// WithServertype defines the attributes of new objects of the servertype, returned by the new_object endpoint
+func WithServertype(servertype string, attributes map[string]any) Option {
+	return func(s *Server) {
+		s.tb.Helper()
+
+		object, err := normalizeObject(attributes)
+		if err != nil {
+			s.tb.Fatalf("adminapitest: invalid attributes of servertype %s: %s", servertype, err)
+		}
+		s.servertypes[servertype] = object
+	}
+}
+
+// WithToken accepts requests authenticated with the security token, like by adminapi.WithToken()
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.tokens = append(s.tokens, token)
+	}
+}
+
+// WithPublicKeys accepts requests signed by one of the keys, like by adminapi.WithSigner()
+func WithPublicKeys(publicKeys ...ssh.PublicKey) Option {
+	return func(s *Server) {
+		s.publicKeys = append(s.publicKeys, publicKeys...)
+	}
+}
+
+// Client returns a client for the server, authenticated with the first token if any. Further
+// options are applied afterward, like adminapi.WithSigner() for servers only accepting SSH keys.
+func (s *Server) Client(options ...adminapi.Option) *adminapi.Client {
+	s.tb.Helper()
+
+	defaults := []adminapi.Option{adminapi.WithBaseURL(s.URL)}
+	if len(s.tokens) > 0 {
+		defaults = append(defaults, adminapi.WithToken(s.tokens[0]))
+	}
+
+	client, err := adminapi.NewClient(append(defaults, options...)...)
+	if err != nil {
+		s.tb.Fatalf("failed to create client: %s", err)
+	}
+
+	return client
+}
+
+// AddObject stores a new object and returns its object_id. Attributes which can't be encoded as JSON fail the test.
+func (s *Server) AddObject(attributes map[string]any) int {
+	s.tb.Helper()
+
+	object, err := normalizeObject(attributes)
+	if err != nil {
+		s.tb.Fatalf("adminapitest: invalid object: %s", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objectID, ok := object["object_id"].(int)
+	if !ok {
+		objectID = s.nextID
+		object["object_id"] = objectID
+	}
+	s.nextID = max(s.nextID, objectID+1)
+	s.objects[objectID] = object
+
+	return objectID
+}
+
+// Object returns a copy of the object with the given object_id, nil if it doesn't exist
+func (s *Server) Object(objectID int) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.objects[objectID]
+	if !ok {
+		return nil
+	}
+
+	return copyObject(object)
+}
+
+// Objects returns copies of all objects, ordered by object_id
+func (s *Server) Objects() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects := make([]map[string]any, 0, len(s.objects))
+	for _, objectID := range slices.Sorted(maps.Keys(s.objects)) {
+		objects = append(objects, copyObject(s.objects[objectID]))
+	}
+
+	return objects
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request: %s", err)
+		return
+	}
+
+	if err = s.authenticate(r.Header, body); err != nil {
+		writeError(w, http.StatusForbidden, "%s", err)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/dataset/query":
+		s.handleQuery(w, body)
+	case "/api/dataset/new_object":
+		s.handleNewObject(w, r.URL.Query().Get("servertype"))
+	case "/api/dataset/commit":
+		s.handleCommit(w, body)
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint %s", r.URL.Path)
+	}
+}
+
+// like {"filters": {"hostname": {"Regexp": "foo.local.*"}}, "restrict": ["hostname", "object_id"], "order_by": "hostname"}
+type queryRequest struct {
+	Filters  adminapi.Filters `json:"filters"`
+	Restrict []string         `json:"restrict"`
+	OrderBy  string           `json:"order_by"`
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, body []byte) {
+	var request queryRequest
+	if err := decodeJSON(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid query: %s", err)
+		return
+	}
+	normalizeValues(request.Filters)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matchedObjects []map[string]any
+	for _, objectID := range slices.Sorted(maps.Keys(s.objects)) {
+		object := s.objects[objectID]
+		matched, err := matchObject(object, request.Filters)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid filter: %s", err)
+			return
+		}
+		if matched {
+			matchedObjects = append(matchedObjects, object)
+		}
+	}
+
+	// sorted before restricting, as the order_by attribute doesn't need to be restricted
+	if request.OrderBy != "" {
+		slices.SortStableFunc(matchedObjects, func(a, b map[string]any) int {
+			return compareValues(a[request.OrderBy], b[request.OrderBy])
+		})
+	}
+
+	result := make([]map[string]any, 0, len(matchedObjects))
+	for _, object := range matchedObjects {
+		result = append(result, restrictObject(object, request.Restrict))
+	}
+
+	writeJSON(w, map[string]any{"status": "success", "result": result})
+}
+
+func (s *Server) handleNewObject(w http.ResponseWriter, servertype string) {
+	attributes, ok := s.servertypes[servertype]
+	if !ok {
+		writeError(w, http.StatusNotFound, "servertype %s does not exist", servertype)
+		return
+	}
+
+	object := copyObject(attributes)
+	object["object_id"] = nil
+	object["servertype"] = servertype
+
+	writeJSON(w, object)
+}
+
+// like {"created": [{"hostname": "new.local"}], "changed": [{"object_id": 1, "state": {"action": "update", "old": "online", "new": "offline"}}], "deleted": [2]}
+type commitRequest struct {
+	Created []map[string]any `json:"created"`
+	Changed []map[string]any `json:"changed"`
+	Deleted []int            `json:"deleted"`
+}
+
+func (s *Server) handleCommit(w http.ResponseWriter, body []byte) {
+	var request commitRequest
+	if err := decodeJSON(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid commit: %s", err)
+		return
+	}
+	for _, object := range slices.Concat(request.Created, request.Changed) {
+		normalizeValues(object)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// like SA, a commit is applied completely or not at all
+	if err := s.validateCommit(request); err != nil {
+		writeJSON(w, map[string]any{"status": "error", "type": "ValidationError", "message": err.Error()})
+		return
+	}
+	if newer := s.newerData(request.Changed); len(newer) > 0 {
+		writeJSON(w, map[string]any{
+			"status":  "error",
+			"type":    "CommitNewerData",
+			"message": "Newer data available",
+			"newer":   newer,
+		})
+		return
+	}
+
+	for _, changes := range request.Changed {
+		applyChanges(s.objects[changes["object_id"].(int)], changes)
+	}
+	for _, objectID := range request.Deleted {
+		delete(s.objects, objectID)
+	}
+	created := make([]map[string]any, 0, len(request.Created))
+	for _, attributes := range request.Created {
+		object := copyObject(attributes)
+		object["object_id"] = s.nextID
+		s.objects[s.nextID] = object
+		s.nextID++
+		created = append(created, copyObject(object))
+	}
+
+	writeJSON(w, map[string]any{"status": "success", "created": created})
+}
+
+// matchObject evaluates the filters like SA, attributes the object doesn't have are null
+func matchObject(object map[string]any, filters adminapi.Filters) (bool, error) {
+	attributes := maps.Clone(object)
+	for attribute := range filters {
+		if _, ok := attributes[attribute]; !ok {
+			attributes[attribute] = nil
+		}
+	}
+
+	return filters.Match(adminapi.NewServerObject(attributes))
+}
+
+// validateCommit checks that all changed and deleted objects exist and all changes are valid
+func (s *Server) validateCommit(request commitRequest) error {
+	for _, changes := range request.Changed {
+		objectID, ok := changes["object_id"].(int)
+		if !ok || s.objects[objectID] == nil {
+			return fmt.Errorf("object %v does not exist", changes["object_id"])
+		}
+		for attribute, change := range changes {
+			if attribute == "object_id" {
+				continue
+			}
+			changeMap, _ := change.(map[string]any)
+			action, _ := changeMap["action"].(string)
+			if action != "update" && action != "multi" {
+				return fmt.Errorf("invalid change of attribute %s of object %d: %v", attribute, objectID, change)
+			}
+		}
+	}
+	for _, objectID := range request.Deleted {
+		if s.objects[objectID] == nil {
+			return fmt.Errorf("object %d does not exist", objectID)
+		}
+	}
+
+	return nil
+}
+
+// newerData returns [object_id, attribute, current value] of all updated attributes whose old value
+// doesn't match the current value anymore, because they were modified concurrently
+func (s *Server) newerData(changed []map[string]any) [][]any {
+	var newer [][]any
+	for _, changes := range changed {
+		object := s.objects[changes["object_id"].(int)]
+		for _, attribute := range slices.Sorted(maps.Keys(changes)) {
+			change, ok := changes[attribute].(map[string]any)
+			if !ok || change["action"] != "update" {
+				continue
+			}
+			if !equalValues(object[attribute], change["old"]) {
+				newer = append(newer, []any{object["object_id"], attribute, object[attribute]})
+			}
+		}
+	}
+
+	return newer
+}
+
+// applyChanges applies the "update" and "multi" changes of one object
+func applyChanges(object map[string]any, changes map[string]any) {
+	for attribute, change := range changes {
+		change, ok := change.(map[string]any)
+		if !ok {
+			continue
+		}
+		if change["action"] == "update" {
+			object[attribute] = change["new"]
+			continue
+		}
+
+		values, _ := object[attribute].([]any)
+		values = slices.DeleteFunc(slices.Clone(values), func(value any) bool {
+			return containsValue(change["remove"], value)
+		})
+		added, _ := change["add"].([]any)
+		for _, value := range added {
+			if !containsValue(values, value) {
+				values = append(values, value)
+			}
+		}
+		object[attribute] = values
+	}
+}
+
+// restrictObject returns a copy of the object with only the given attributes, all if none are given
+func restrictObject(object map[string]any, restrict []string) map[string]any {
+	if len(restrict) == 0 {
+		return copyObject(object)
+	}
+
+	restricted := make(map[string]any, len(restrict))
+	for _, attribute := range restrict {
+		restricted[attribute] = copyValue(object[attribute])
+	}
+
+	return restricted
+}
+
+// normalizeObject converts the attributes to the types they have after a JSON round trip, like []any
+// for multi attributes and int for integral numbers, so they can be compared with the requests
+func normalizeObject(attributes map[string]any) (map[string]any, error) {
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("can't be encoded as JSON: %w", err)
+	}
+
+	object := map[string]any{}
+	if err = decodeJSON(encoded, &object); err != nil {
+		return nil, fmt.Errorf("can't be decoded: %w", err)
+	}
+	normalizeValues(object)
+
+	return object, nil
+}
+
+func copyObject(object map[string]any) map[string]any {
+	copied := make(map[string]any, len(object))
+	for attribute, value := range object {
+		copied[attribute] = copyValue(value)
+	}
+
+	return copied
+}
+
+func copyValue(value any) any {
+	if values, ok := value.([]any); ok {
+		return slices.Clone(values)
+	}
+
+	return value
+}
+
+// decodeJSON decodes the data keeping all numbers as json.Number, see normalizeValues()
+func decodeJSON(data []byte, target any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decoder.Decode(target)
+}
+
+// normalizeValues converts all json.Number values of the map to int if they are integral, or else to float64
+func normalizeValues(values map[string]any) {
+	for key, value := range values {
+		values[key] = normalizeValue(value)
+	}
+}
+
+func normalizeValue(value any) any {
+	switch typed := value.(type) {
+	case json.Number:
+		if intVal, err := typed.Int64(); err == nil {
+			return int(intVal)
+		}
+		floatVal, _ := typed.Float64()
+		return floatVal
+	case []any:
+		for idx, elem := range typed {
+			typed[idx] = normalizeValue(elem)
+		}
+	case map[string]any:
+		normalizeValues(typed)
+	}
+
+	return value
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// writeError responds with an error like SA does, like {"error": {"message": "..."}}
+func writeError(w http.ResponseWriter, statusCode int, format string, args ...any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"message": fmt.Sprintf(format, args...)},
+	})
+}
@@ -0,0 +1,205 @@
+package adminapitest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/innogames/serveradmin-go-client/adminapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestServer(t *testing.T, options ...Option) *Server {
+	t.Helper()
+
+	objects := WithObjects(
+		map[string]any{"hostname": "web02.example.com", "servertype": "vm", "memory": 2048, "tags": []string{"web"}},
+		map[string]any{"hostname": "web01.example.com", "servertype": "vm", "memory": 4096, "tags": []string{"web", "prod"}},
+		map[string]any{"object_id": 10, "hostname": "db01.example.com", "servertype": "vm", "memory": 8192, "tags": []string{}},
+	)
+	servertype := WithServertype("vm", map[string]any{"hostname": nil, "memory": 1024, "tags": []string{}})
+
+	return NewServer(t, append([]Option{objects, servertype}, options...)...)
+}
+
+func TestQuery(t *testing.T) {
+	server := newTestServer(t)
+	client := server.Client()
+
+	query, err := client.FromQuery("hostname=regexp(^web) memory=GreaterThan(1024)")
+	require.NoError(t, err)
+	query.SetAttributes([]string{"hostname", "memory"})
+	query.OrderBy("hostname")
+
+	objects, err := query.All()
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "web01.example.com", objects[0].Get("hostname"))
+	assert.Equal(t, 2, objects[0].ObjectID())
+	assert.Equal(t, 4096, objects[0].Get("memory"))
+	assert.Equal(t, "web02.example.com", objects[1].Get("hostname"))
+	_, err = objects[0].GetMulti("tags")
+	require.Error(t, err, "tags are not restricted")
+
+	query = client.NewQuery(adminapi.Filters{"tags": "prod"})
+	query.SetAttributes([]string{"hostname", "tags"})
+	object, err := query.One()
+	require.NoError(t, err)
+	tags, err := object.GetStringSlice("tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web", "prod"}, tags)
+
+	// order_by doesn't have to be restricted
+	query = client.NewQuery(adminapi.Filters{})
+	query.SetAttributes([]string{"object_id"})
+	query.OrderBy("hostname")
+	objects, err = query.All()
+	require.NoError(t, err)
+	objectIDs := make([]int, len(objects))
+	for idx, object := range objects {
+		objectIDs[idx] = object.ObjectID()
+	}
+	assert.Equal(t, []int{10, 2, 1}, objectIDs)
+
+	query = client.NewQuery(adminapi.Filters{"hostname": adminapi.Filter{"Nope": 1}})
+	_, err = query.All()
+	require.EqualError(t, err, "HTTP error 400 Bad Request: invalid filter: attribute hostname: unknown filter function Nope")
+}
+
+func TestCommit(t *testing.T) {
+	server := newTestServer(t)
+	client := server.Client()
+
+	// create
+	object, err := client.NewObject("vm")
+	require.NoError(t, err)
+	assert.Equal(t, 1024, object.Get("memory"))
+	require.NoError(t, object.Set("hostname", "new.example.com"))
+	require.NoError(t, object.Commit())
+	assert.Equal(t, 11, object.ObjectID())
+	assert.Equal(t, "new.example.com", server.Object(11)["hostname"])
+
+	// change
+	query := client.NewQuery(adminapi.Filters{"hostname": "web01.example.com"})
+	query.SetAttributes([]string{"hostname", "memory", "tags"})
+	object, err = query.One()
+	require.NoError(t, err)
+	require.NoError(t, object.Set("memory", 16384))
+	tags, err := object.GetMulti("tags")
+	require.NoError(t, err)
+	tags.Remove("prod")
+	tags.Add("staging")
+	require.NoError(t, object.Commit())
+	assert.Equal(t, 16384, server.Object(2)["memory"])
+	assert.Equal(t, []any{"web", "staging"}, server.Object(2)["tags"])
+
+	// conflict with a concurrent change
+	query = client.NewQuery(adminapi.Filters{"hostname": "web02.example.com"})
+	query.SetAttributes([]string{"hostname", "memory"})
+	object, err = query.One()
+	require.NoError(t, err)
+	server.AddObject(map[string]any{"object_id": 1, "hostname": "web02.example.com", "memory": 3072})
+	require.NoError(t, object.Set("memory", 1024))
+	err = object.Commit()
+	var conflict *adminapi.ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, adminapi.ConflictError{ObjectID: 1, Attribute: "memory", Value: 3072}, *conflict)
+	assert.Equal(t, 3072, server.Object(1)["memory"])
+
+	// delete
+	query = client.NewQuery(adminapi.Filters{"hostname": adminapi.Regexp("^db")})
+	require.NoError(t, query.Delete(1))
+	_, err = query.Commit()
+	require.NoError(t, err)
+	assert.Nil(t, server.Object(10))
+	assert.Len(t, server.Objects(), 3)
+
+	_, err = client.NewObject("nope")
+	require.EqualError(t, err, "HTTP error 404 Not Found: servertype nope does not exist")
+}
+
+func TestAuthentication(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+
+	server := newTestServer(t, WithToken("secret"), WithPublicKeys(signer.PublicKey()))
+
+	tests := []struct {
+		name    string
+		option  adminapi.Option
+		wantErr string
+	}{
+		{"token", adminapi.WithToken("secret"), ""},
+		{"signer", adminapi.WithSigner(signer), ""},
+		{"one of multiple keys", adminapi.WithSigners(otherSigner, signer), ""},
+		{"wrong token", adminapi.WithToken("wrong"), "HTTP error 403 Forbidden: unknown application"},
+		{"unauthorized key", adminapi.WithSigner(otherSigner), "HTTP error 403 Forbidden: none of the public keys is authorized"},
+		{"no authentication", adminapi.WithAuthenticator(adminapi.NoAuth()), "HTTP error 403 Forbidden: missing authentication headers"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := server.Client(test.option, adminapi.WithRetryPolicy(adminapi.NoRetries()))
+			query := client.NewQuery(adminapi.Filters{"hostname": "web01.example.com"})
+			count, err := query.Count()
+			if test.wantErr != "" {
+				require.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+// fatalTB records the message of Fatalf() instead of failing the test
+type fatalTB struct {
+	testing.TB
+	message string
+}
+
+func (tb *fatalTB) Fatalf(format string, args ...any) {
+	tb.message = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestInvalidObjects(t *testing.T) {
+	fatal := func(f func(tb testing.TB)) string {
+		tb := &fatalTB{TB: t}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			f(tb)
+		}()
+		<-done
+		return tb.message
+	}
+
+	message := fatal(func(tb testing.TB) {
+		NewServer(tb, WithObjects(map[string]any{"hostname": "web01", "broken": make(chan int)}))
+	})
+	assert.Equal(t, "adminapitest: invalid object: can't be encoded as JSON: json: unsupported type: chan int", message)
+
+	message = fatal(func(tb testing.TB) {
+		NewServer(tb, WithServertype("vm", map[string]any{"broken": func() {}}))
+	})
+	assert.Equal(t, "adminapitest: invalid attributes of servertype vm: can't be encoded as JSON: json: unsupported type: func()", message)
+
+	server := newTestServer(t)
+	message = fatal(func(tb testing.TB) {
+		server.tb = tb
+		server.AddObject(map[string]any{"broken": make(chan int)})
+	})
+	assert.Contains(t, message, "adminapitest: invalid object")
+	assert.Len(t, server.Objects(), 3)
+}
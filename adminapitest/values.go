@@ -0,0 +1,72 @@
+package adminapitest
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// compareValues orders numbers by value and all other values by their string representation, nil first
+func compareValues(a, b any) int {
+	if a == nil || b == nil {
+		return cmp.Compare(boolInt(a != nil), boolInt(b != nil))
+	}
+
+	aNumber, aIsNumber := toFloat(a)
+	bNumber, bIsNumber := toFloat(b)
+	if aIsNumber && bIsNumber {
+		return cmp.Compare(aNumber, bNumber)
+	}
+
+	return strings.Compare(toString(a), toString(b))
+}
+
+// equalValues compares numbers by value, multi attributes as sets and all other values by their
+// string representation, so a filter like hostname=123 matches the string "123"
+func equalValues(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	aValues, aIsMulti := a.([]any)
+	bValues, bIsMulti := b.([]any)
+	if aIsMulti || bIsMulti {
+		return aIsMulti && bIsMulti && len(aValues) == len(bValues) &&
+			!slices.ContainsFunc(aValues, func(value any) bool { return !containsValue(bValues, value) })
+	}
+
+	return compareValues(a, b) == 0
+}
+
+func containsValue(values any, value any) bool {
+	list, _ := values.([]any)
+	return slices.ContainsFunc(list, func(elem any) bool { return equalValues(elem, value) })
+}
+
+func toFloat(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case int:
+		return float64(typed), true
+	case float64:
+		return typed, true
+	default:
+		return 0, false
+	}
+}
+
+func toString(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	return fmt.Sprint(value)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/innogames/serveradmin-go-client/adminapi"
+	"golang.org/x/term"
 )
 
 // adminapi CLI entry point
@@ -14,9 +16,13 @@ func main() {
 	var attributes string
 	var orderBy string
 	var onlyOne bool
+	var profile string
+	var verbose bool
 	flag.StringVar(&attributes, "a", "hostname", "Attributes to fetch")
 	flag.StringVar(&orderBy, "order", "", "Attributes to order by the result")
 	flag.BoolVar(&onlyOne, "one", false, "Make sure exactly one server matches with the query")
+	flag.BoolVar(&verbose, "v", false, "Print the effective query to stderr")
+	flag.StringVar(&profile, "profile", os.Getenv("SERVERADMIN_PROFILE"), "Profile of ~/.adminapirc to use, like \"staging\"")
 
 	flag.Parse()
 
@@ -26,7 +32,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	q, err := adminapi.FromQuery(query)
+	client, err := adminapi.NewClient(
+		adminapi.WithProfile(profile),
+		adminapi.WithPassphraseFunc(promptPassphrase),
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	q, err := client.FromQuery(query)
 	if err != nil {
 		fmt.Println("Error parsing query:", err)
 		os.Exit(1)
@@ -35,6 +50,10 @@ func main() {
 	attributeList := strings.Split(attributes, ",")
 	q.SetAttributes(attributeList)
 
+	if verbose {
+		fmt.Fprintln(os.Stderr, "Query:", q.String())
+	}
+
 	servers, err := q.All()
 	if err != nil {
 		fmt.Println(err)
@@ -54,12 +73,26 @@ func main() {
 	}
 
 	/* examples
-	server := q.One()
-	q.Set("backup_disabled", "true")
-	q.Commit()
+	server, err := q.One()
+	err = server.Set("backup_disabled", true)
+	err = server.Commit()
 
-	new, err := adminapi.NewServer("vm")
-	new.Set("hostname", "test")
-	new.Commit()
+	new, err := adminapi.NewObject("vm")
+	err = new.Set("hostname", "test")
+	err = new.Commit()
 	*/
 }
+
+// promptPassphrase asks on the terminal for the passphrase of an encrypted private key
+func promptPassphrase(keyPath string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("stdin is not a terminal")
+	}
+
+	fmt.Fprintf(os.Stderr, "Enter passphrase for %s: ", keyPath)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	return passphrase, err
+}